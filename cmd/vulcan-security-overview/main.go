@@ -1,14 +1,23 @@
 package main
 
 import (
+	"context"
+	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"log"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strings"
+	"syscall"
 
 	insights "github.com/adevinta/security-overview"
 	"github.com/adevinta/security-overview/report"
+	"github.com/adevinta/security-overview/vulcan/client"
+	"github.com/adevinta/security-overview/vulcan/persistence"
 	uuid "github.com/satori/go.uuid"
 )
 
@@ -24,10 +33,44 @@ Takes a path to the json file. for instance ./report.json`)
 	assetsURL  = flag.String("assetsurl", "", "[required with regen] specifies the base url where the manage")
 	detailsURL = flag.String("detailsurl", "", "[required with regen] specifies the base url of the details")
 	output     = flag.String("output", "", "[required with regen] specifies the directory to save regenerated report")
-	check      = flag.String("check", "", `generates the security overview for test pourposes from a single check report stored in 
+	offline    = flag.Bool("offline", false, "generate the report only from the data stored in the cache defined in the config, failing if it is not cached")
+	compare    = flag.String("compare-scan", "", `include in the report the changes since a previous scan. Takes either the ID of the scan
+or the path to the json file with its report data, for instance ./team-name.json`)
+	progress = flag.Bool("progress", false, "show a progress bar while the reports of the checks are retrieved")
+	check    = flag.String("check", "", `generates the security overview for test pourposes from a single check report stored in 
 a file. The only other required flag is -config. Example: vulcan-security-overview -config ".security-overview.toml" -check check_report.json`)
+	portfolio = flag.String("portfolio", "", `generates a report merging the scans of several teams. Takes the path to a CSV file with a
+line per scan: team-id,team-name,scan-id. The only other required flags are -config and -portfolio-name`)
+	portfolioName = flag.String("portfolio-name", "", "[required with portfolio] name of the portfolio report. Ex: -portfolio-name=\"Marketplaces\"")
 )
 
+// Exit codes returned by the CLI when the generation of a report fails.
+const (
+	exitError        = 1
+	exitScanNotFound = 2
+	exitUnauthorized = 3
+	exitUpstream     = 4
+)
+
+func exitCode(err error) int {
+	switch {
+	case errors.Is(err, persistence.ErrScanNotFound):
+		return exitScanNotFound
+	case errors.Is(err, client.ErrUnauthorized):
+		return exitUnauthorized
+	case errors.Is(err, client.ErrUpstream):
+		return exitUpstream
+	default:
+		return exitError
+	}
+}
+
+// exit logs the error and exits with the code corresponding to it.
+func exit(err error) {
+	log.Print(err)
+	os.Exit(exitCode(err))
+}
+
 func checkParams() bool {
 
 	if (*scanID == "" || *teamName == "" || *configFile == "" || *teamID == "") && *regen == "" {
@@ -43,13 +86,50 @@ func checkRegenerateParams() bool {
 
 func main() {
 	flag.Parse()
+
+	// Cancel the generation of the report on SIGINT or SIGTERM.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if flag.Arg(0) == "resign" {
+		urls, err := resign(ctx, flag.Args()[1:])
+		if err != nil {
+			exit(err)
+		}
+		for _, u := range urls {
+			fmt.Println(u)
+		}
+		return
+	}
+	if flag.Arg(0) == "clean" {
+		files, err := clean(ctx, flag.Args()[1:])
+		if err != nil {
+			exit(err)
+		}
+		for _, f := range files {
+			fmt.Printf("%s/%s\n", f.Bucket, f.Key)
+		}
+		return
+	}
 	if *check != "" {
 		if *configFile == "" {
 			flag.Usage()
+			return
 		}
-		err := generateFromFile(*check, *configFile)
+		err := generateFromFile(ctx, *check, *configFile)
 		if err != nil {
-			panic(err)
+			exit(err)
+		}
+		return
+	}
+	if *portfolio != "" {
+		if *configFile == "" || *portfolioName == "" {
+			flag.Usage()
+			return
+		}
+		err := generatePortfolio(ctx, *portfolio, *portfolioName, *configFile)
+		if err != nil {
+			exit(err)
 		}
 		return
 	}
@@ -64,55 +144,146 @@ func main() {
 		}
 		err := regenerateReport()
 		if err != nil {
-			panic(err)
+			exit(err)
 		}
 		return
 	}
 
-	dr, err := insights.NewDetailedReport(*configFile, *teamName, *scanID, *teamID)
+	dr, err := insights.NewDetailedReport(ctx, *configFile, *teamName, *scanID, *teamID)
 	if err != nil {
-		fmt.Printf("%v", err)
-		os.Exit(1)
+		exit(err)
 	}
 
-	err = dr.GenerateLocalFiles()
-	if err != nil {
-		fmt.Printf("%v", err)
-		os.Exit(1)
+	if *offline {
+		dr.SetOffline(true)
 	}
 
-	err = dr.UploadFilesToS3()
+	if *compare != "" {
+		dr.SetCompareScan(*compare)
+	}
+
+	bar := &progressBar{w: os.Stderr}
+	if *progress {
+		dr.SetProgress(bar.update)
+	}
+
+	err = dr.Generate(ctx)
+	bar.finish()
 	if err != nil {
-		fmt.Printf("%v", err)
-		os.Exit(1)
+		exit(err)
 	}
 }
 
-func generateFromFile(path string, config string) error {
+func generateFromFile(ctx context.Context, path string, config string) error {
 	teamName := "Team 1"
 	uuid, err := uuid.NewV1()
 	if err != nil {
 		return err
 	}
 	id := uuid.String()
-	dr, err := insights.NewDetailedReport(config, teamName, id, id)
+	dr, err := insights.NewDetailedReport(ctx, config, teamName, id, id)
+	if err != nil {
+		return err
+	}
+
+	return dr.GenerateFromCheck(ctx, path)
+}
+
+// resign signs again the URLs of the reports of a team published in a given
+// date, with the flags of the resign command.
+func resign(ctx context.Context, args []string) ([]string, error) {
+	fs := flag.NewFlagSet("resign", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s resign -config <config> -team-name <team-name> -date <YYYY-MM-DD>\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	config := fs.String("config", "", "[required] config file")
+	team := fs.String("team-name", "", "[required] Team name the reports belong to. Ex: -team-name=\"Purple Team\"")
+	date := fs.String("date", "", "[required] date of the reports. Ex: -date=2023-02-01")
+	expiry := fs.Duration("expiry", 0, "time the URLs are valid, by default the presign_expiry defined in the config. Ex: -expiry=72h")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if *config == "" || *team == "" || *date == "" {
+		fs.Usage()
+		return nil, errors.New("missing required flags")
+	}
+	return insights.ResignReports(ctx, *config, *team, *date, *expiry)
+}
+
+// clean deletes the reports of a team out of the retention, with the flags of
+// the clean command.
+func clean(ctx context.Context, args []string) ([]insights.ExpiredFile, error) {
+	fs := flag.NewFlagSet("clean", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s clean -config <config> -team-name <team-name> [-keep <reports>] [-days <days>] [-dry-run]\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	config := fs.String("config", "", "[required] config file")
+	team := fs.String("team-name", "", "[required] Team name the reports belong to. Ex: -team-name=\"Purple Team\"")
+	keep := fs.Int("keep", 0, "number of reports kept, the last ones. At least -keep or -days is required")
+	days := fs.Int("days", 0, "number of days the reports are kept. At least -keep or -days is required")
+	dryRun := fs.Bool("dry-run", false, "only list the files of the reports that would be deleted")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if *config == "" || *team == "" || (*keep == 0 && *days == 0) {
+		fs.Usage()
+		return nil, errors.New("missing required flags")
+	}
+	return insights.CleanReports(ctx, *config, *team, insights.Retention{Keep: *keep, Days: *days}, *dryRun)
+}
+
+func generatePortfolio(ctx context.Context, path, name, config string) error {
+	teams, err := readTeamScans(path)
+	if err != nil {
+		return err
+	}
+	pr, err := insights.NewPortfolioReport(config, name, teams)
 	if err != nil {
-		fmt.Printf("%v", err)
-		os.Exit(1)
+		return err
+	}
+
+	if *offline {
+		pr.SetOffline(true)
 	}
 
-	err = dr.GenerateLocalFilesFromCheck(path)
+	bar := &progressBar{w: os.Stderr}
+	if *progress {
+		pr.SetProgress(bar.update)
+	}
+
+	err = pr.Generate(ctx)
+	bar.finish()
+	return err
+}
+
+// readTeamScans reads the scans of the teams of a portfolio from a CSV file
+// with a line per scan: team-id,team-name,scan-id.
+func readTeamScans(path string) ([]insights.TeamScan, error) {
+	f, err := os.Open(path)
 	if err != nil {
-		fmt.Printf("%v", err)
-		os.Exit(1)
+		return nil, err
 	}
+	defer f.Close()
 
-	err = dr.UploadFilesToS3()
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = 3
+	r.Comment = '#'
+	records, err := r.ReadAll()
 	if err != nil {
-		fmt.Printf("%v", err)
-		os.Exit(1)
+		return nil, fmt.Errorf("invalid portfolio %s: %w", path, err)
 	}
-	return nil
+
+	var teams []insights.TeamScan
+	for _, record := range records {
+		teams = append(teams, insights.TeamScan{
+			TeamID:   strings.TrimSpace(record[0]),
+			TeamName: strings.TrimSpace(record[1]),
+			ScanID:   strings.TrimSpace(record[2]),
+		})
+	}
+	return teams, nil
 }
 
 func regenerateReport() error {
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/adevinta/security-overview/vulcan"
+)
+
+const (
+	progressBarWidth    = 40
+	progressBarInterval = 100 * time.Millisecond
+)
+
+// progressBar draws the progress of the collection of the reports of a scan
+// in a single line of a terminal.
+type progressBar struct {
+	w     io.Writer
+	last  time.Time
+	drawn bool
+}
+
+// update redraws the bar. To not flood the terminal, it is redrawn at most
+// once per progressBarInterval, except when the collection finishes.
+func (b *progressBar) update(p vulcan.Progress) {
+	if p.Done != p.Total && time.Since(b.last) < progressBarInterval {
+		return
+	}
+	b.last = time.Now()
+	b.drawn = true
+
+	if p.Total < 0 {
+		fmt.Fprintf(b.w, "\r%d checks processed, %d failed", p.Done, p.Failed)
+		return
+	}
+	filled := progressBarWidth
+	if p.Total > p.Done {
+		filled = progressBarWidth * p.Done / p.Total
+	}
+	eta := "-"
+	if p.ETA > 0 {
+		eta = p.ETA.Round(time.Second).String()
+	}
+	fmt.Fprintf(b.w, "\r[%s%s] %d/%d checks, %d failed, ETA %s ",
+		strings.Repeat("=", filled), strings.Repeat(" ", progressBarWidth-filled),
+		p.Done, p.Total, p.Failed, eta)
+	if p.Done == p.Total {
+		b.finish()
+	}
+}
+
+// finish ends the line of the bar, if it has been drawn since the last call.
+func (b *progressBar) finish() {
+	if b.drawn {
+		fmt.Fprintln(b.w)
+		b.drawn = false
+	}
+}
@@ -1,23 +1,44 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"time"
 
 	"github.com/BurntSushi/toml"
+
+	"github.com/adevinta/security-overview/storage"
 )
 
 const (
-	defResultsWorkers = 5
+	defResultsWorkers  = 5
+	defRequestTimeout  = 30 * time.Second
+	defRetries         = 3
+	defRetryBackoff    = time.Second
+	defMaxRetryBackoff = 30 * time.Second
+	defHistoryScans    = 10
+	defGroupieKeep     = 10
+	defStorageUploads  = 5
 )
 
 type Config struct {
-	Analytics   analytics         `toml:"analytics"`
-	S3          s3Config          `toml:"s3"`
-	Persistence persistenceConfig `toml:"persistence"`
-	Results     resultsConfig     `toml:"results"`
-	Proxy       proxy             `toml:"proxy"`
-	General     generalConfig     `toml:"general"`
-	Endpoints   endpointsConfig   `toml:"endpoints"`
+	Analytics    analytics          `toml:"analytics"`
+	S3           s3Config           `toml:"s3"`
+	Storage      storageConfig      `toml:"storage"`
+	Source       sourceConfig       `toml:"source"`
+	Persistence  persistenceConfig  `toml:"persistence"`
+	Results      resultsConfig      `toml:"results"`
+	Proxy        proxy              `toml:"proxy"`
+	General      generalConfig      `toml:"general"`
+	Endpoints    endpointsConfig    `toml:"endpoints"`
+	HTTP         httpConfig         `toml:"http"`
+	Cache        cacheConfig        `toml:"cache"`
+	History      historyConfig      `toml:"history"`
+	Groupie      groupieConfig      `toml:"groupie"`
+	SLA          SLAConfig          `toml:"sla"`
+	Suppressions suppressionsConfig `toml:"suppressions"`
+	Policy       policyConfig       `toml:"policy"`
+	Inventory    inventoryConfig    `toml:"inventory"`
 }
 
 type analytics struct {
@@ -25,21 +46,137 @@ type analytics struct {
 }
 
 type s3Config struct {
-	Upload        bool   `toml:"upload"`
 	Region        string `toml:"region"`
 	Endpoint      string `toml:"endpoint"`
 	PrivateBucket string `toml:"private_bucket"`
 	PublicBucket  string `toml:"public_bucket"`
 	PathStyle     bool   `toml:"path_style"`
+	// URLs the files of the buckets are published under, for instance a CDN.
+	// By default the private files are linked through the proxy and the
+	// public ones directly from the bucket.
+	PrivateBaseURL string `toml:"private_base_url"`
+	PublicBaseURL  string `toml:"public_base_url"`
+	// Time the pre-signed URLs of the files of the private bucket are valid.
+	// Zero links them under the private base URL instead.
+	PresignExpiry time.Duration `toml:"presign_expiry"`
+	// Options of the uploads.
+	PartSizeMB      int64  `toml:"part_size_mb"`     // Size of the parts of the multipart uploads, by default 5.
+	Concurrency     int    `toml:"concurrency"`      // Parts uploaded in parallel, by default 5.
+	CacheControl    string `toml:"cache_control"`    // Empty for no Cache-Control.
+	ContentEncoding string `toml:"content_encoding"` // gzip compresses the text files, empty disables it.
+	SSE             string `toml:"sse"`              // AES256 or aws:kms, empty for the default of the bucket.
+	SSEKMSKeyID     string `toml:"sse_kms_key_id"`
+}
+
+type storageConfig struct {
+	Type    string `toml:"type"`     // s3 (default), dir or memory
+	Dir     string `toml:"dir"`      // Root of the buckets with dir, by default the local temp dir.
+	BaseURL string `toml:"base_url"` // URL the dir is published under, empty for local paths.
+	Uploads int    `toml:"uploads"`  // Files of a report published in parallel, by default 5.
+}
+
+type sourceConfig struct {
+	Type string `toml:"type"` // vulcan (default) or dir
+	Dir  string `toml:"dir"`
 }
 
 type persistenceConfig struct {
-	Endpoint string `toml:"endpoint"`
+	Endpoint       string        `toml:"endpoint"`
+	RequestTimeout time.Duration `toml:"request_timeout"`
+	PageSize       int           `toml:"page_size"`
+	Auth           AuthConfig    `toml:"auth"`
 }
 
 type resultsConfig struct {
-	Endpoint string `toml:"endpoint"`
-	Workers  int    `toml:"workers"`
+	Endpoint        string        `toml:"endpoint"`
+	Workers         int           `toml:"workers"`
+	RequestTimeout  time.Duration `toml:"request_timeout"`
+	Retries         int           `toml:"retries"` // A negative value disables the retries.
+	RetryBackoff    time.Duration `toml:"retry_backoff"`
+	MaxRetryBackoff time.Duration `toml:"max_retry_backoff"`
+	ErrorPolicy     string        `toml:"error_policy"` // collect-all (default) or first-error
+	RateLimit       float64       `toml:"rate_limit"`   // Requests per second, zero means no limit.
+	Auth            AuthConfig    `toml:"auth"`
+}
+
+// AuthConfig contains the credentials used to authenticate against an API.
+// The token and the password can be read from the environment variables
+// defined in TokenEnv and PasswordEnv, that take precedence over the values
+// set in the config file.
+type AuthConfig struct {
+	Token       string `toml:"token"`
+	TokenEnv    string `toml:"token_env"`
+	Username    string `toml:"username"`
+	Password    string `toml:"password"`
+	PasswordEnv string `toml:"password_env"`
+	ClientCert  string `toml:"client_cert"`
+	ClientKey   string `toml:"client_key"`
+}
+
+func (a *AuthConfig) readEnv() {
+	if a.TokenEnv != "" {
+		if v, ok := os.LookupEnv(a.TokenEnv); ok {
+			a.Token = v
+		}
+	}
+	if a.PasswordEnv != "" {
+		if v, ok := os.LookupEnv(a.PasswordEnv); ok {
+			a.Password = v
+		}
+	}
+}
+
+type httpConfig struct {
+	CABundle            string        `toml:"ca_bundle"`
+	Proxy               string        `toml:"proxy"`
+	MaxIdleConns        int           `toml:"max_idle_conns"`
+	MaxIdleConnsPerHost int           `toml:"max_idle_conns_per_host"`
+	MaxConnsPerHost     int           `toml:"max_conns_per_host"`
+	IdleConnTimeout     time.Duration `toml:"idle_conn_timeout"`
+	DisableKeepAlives   bool          `toml:"disable_keep_alives"`
+	DisableCompression  bool          `toml:"disable_compression"`
+}
+
+type cacheConfig struct {
+	Dir       string        `toml:"dir"` // An empty dir disables the cache.
+	TTL       time.Duration `toml:"ttl"`
+	MaxSizeMB int64         `toml:"max_size_mb"`
+	Offline   bool          `toml:"offline"`
+}
+
+type historyConfig struct {
+	Type   string `toml:"type"` // file or s3, empty disables the history.
+	Dir    string `toml:"dir"`
+	Bucket string `toml:"bucket"`
+	Prefix string `toml:"prefix"`
+	Scans  int    `toml:"scans"` // Number of scans shown in the charts.
+}
+
+type groupieConfig struct {
+	Type string `toml:"type"` // memory (default) or file
+	Dir  string `toml:"dir"`
+	Keep int    `toml:"keep"` // Number of scans kept per check.
+}
+
+// SLAConfig contains the number of days the findings of every severity can be
+// open before breaching the SLA. Zero means no SLA.
+type SLAConfig struct {
+	Critical int `toml:"critical"`
+	High     int `toml:"high"`
+	Medium   int `toml:"medium"`
+	Low      int `toml:"low"`
+}
+
+type suppressionsConfig struct {
+	File string `toml:"file"` // An empty file disables the suppressions.
+}
+
+type policyConfig struct {
+	File string `toml:"file"` // An empty file keeps the scores of the findings.
+}
+
+type inventoryConfig struct {
+	File string `toml:"file"` // CSV or JSON file, empty disables the enrichment.
 }
 
 type proxy struct {
@@ -47,14 +184,15 @@ type proxy struct {
 }
 
 type generalConfig struct {
-	LocalTempDir      string `toml:"local_temp_dir"`
-	CompanyName       string `toml:"company_name"`
-	SupportEmail      string `toml:"support_email"`
-	ContactEmail      string `toml:"contact_email"`
-	ContactChannel    string `toml:"contact_channel"`
-	DocumentationLink string `toml:"documentation_link"`
-	RoadmapLink       string `toml:"roadmap_link"`
-	Jira              string `toml:"jira"`
+	LocalTempDir      string        `toml:"local_temp_dir"`
+	CompanyName       string        `toml:"company_name"`
+	SupportEmail      string        `toml:"support_email"`
+	ContactEmail      string        `toml:"contact_email"`
+	ContactChannel    string        `toml:"contact_channel"`
+	DocumentationLink string        `toml:"documentation_link"`
+	RoadmapLink       string        `toml:"roadmap_link"`
+	Jira              string        `toml:"jira"`
+	Timeout           time.Duration `toml:"timeout"` // Deadline for the collection of the data of the scans, publishing excluded.
 }
 
 type endpointsConfig struct {
@@ -74,10 +212,50 @@ func ReadConfig(configFile string) (Config, error) {
 		return Config{}, err
 	}
 
+	if err := config.validate(); err != nil {
+		return Config{}, err
+	}
+
+	config.Persistence.Auth.readEnv()
+	config.Results.Auth.readEnv()
+
 	// Parse default config values.
 	if config.Results.Workers == 0 {
 		config.Results.Workers = defResultsWorkers
 	}
+	if config.Persistence.RequestTimeout == 0 {
+		config.Persistence.RequestTimeout = defRequestTimeout
+	}
+	if config.Results.RequestTimeout == 0 {
+		config.Results.RequestTimeout = defRequestTimeout
+	}
+	if config.Results.Retries == 0 {
+		config.Results.Retries = defRetries
+	}
+	if config.Results.RetryBackoff == 0 {
+		config.Results.RetryBackoff = defRetryBackoff
+	}
+	if config.Results.MaxRetryBackoff == 0 {
+		config.Results.MaxRetryBackoff = defMaxRetryBackoff
+	}
+	if config.History.Scans == 0 {
+		config.History.Scans = defHistoryScans
+	}
+	if config.Groupie.Keep == 0 {
+		config.Groupie.Keep = defGroupieKeep
+	}
+	if config.Storage.Uploads == 0 {
+		config.Storage.Uploads = defStorageUploads
+	}
 
 	return config, nil
 }
+
+// validate returns an error if the config contains unsupported values, so
+// they are rejected before any report is generated.
+func (c Config) validate() error {
+	if err := storage.CheckEncoding(c.S3.ContentEncoding); err != nil {
+		return fmt.Errorf("invalid content_encoding of the s3 section: %w", err)
+	}
+	return nil
+}
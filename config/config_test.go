@@ -0,0 +1,86 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadConfigContentEncoding(t *testing.T) {
+	tests := []struct {
+		name     string
+		encoding string
+		wantErr  bool
+	}{
+		{name: "none", encoding: ""},
+		{name: "gzip", encoding: "gzip"},
+		{name: "brotli", encoding: "br", wantErr: true},
+		{name: "unknown", encoding: "zip", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "config.toml")
+			content := "[s3]\nprivate_bucket = \"reports\"\ncontent_encoding = \"" + tt.encoding + "\"\n"
+			if err := os.WriteFile(file, []byte(content), 0600); err != nil {
+				t.Fatal(err)
+			}
+			conf, err := ReadConfig(file)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err == nil && conf.S3.ContentEncoding != tt.encoding {
+				t.Errorf("unexpected content encoding: got %q, want %q", conf.S3.ContentEncoding, tt.encoding)
+			}
+		})
+	}
+}
+
+func TestReadConfigAuthEnv(t *testing.T) {
+	tests := []struct {
+		name         string
+		env          map[string]string
+		wantToken    string
+		wantPassword string
+	}{
+		{name: "config values", wantToken: "config-token", wantPassword: "config-password"},
+		{
+			name:         "env values",
+			env:          map[string]string{"TEST_TOKEN": "env-token", "TEST_PASSWORD": "env-password"},
+			wantToken:    "env-token",
+			wantPassword: "env-password",
+		},
+		{
+			name:         "empty env values",
+			env:          map[string]string{"TEST_TOKEN": "", "TEST_PASSWORD": ""},
+			wantToken:    "",
+			wantPassword: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			file := filepath.Join(t.TempDir(), "config.toml")
+			auth := "token = \"config-token\"\ntoken_env = \"TEST_TOKEN\"\nusername = \"user\"\npassword = \"config-password\"\npassword_env = \"TEST_PASSWORD\"\n"
+			content := "[s3]\nprivate_bucket = \"reports\"\n[persistence.auth]\n" + auth + "[results.auth]\n" + auth
+			if err := os.WriteFile(file, []byte(content), 0600); err != nil {
+				t.Fatal(err)
+			}
+			conf, err := ReadConfig(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// The variables set, even empty, take precedence over the
+			// values of the config.
+			for _, a := range []AuthConfig{conf.Persistence.Auth, conf.Results.Auth} {
+				if a.Token != tt.wantToken {
+					t.Errorf("unexpected token: got %q, want %q", a.Token, tt.wantToken)
+				}
+				if a.Password != tt.wantPassword {
+					t.Errorf("unexpected password: got %q, want %q", a.Password, tt.wantPassword)
+				}
+			}
+		})
+	}
+}
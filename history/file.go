@@ -0,0 +1,67 @@
+package history
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/adevinta/security-overview/vulcan"
+)
+
+// FileStore stores the history of every team in JSON files in a local
+// directory. It is not safe to use it from concurrent processes.
+type FileStore struct {
+	Dir string
+}
+
+// Record adds the entry to the history of the team.
+func (s *FileStore) Record(ctx context.Context, teamID string, e Entry) error {
+	return record(ctx, s, teamID, e)
+}
+
+// Last returns the last n entries of the history of the team.
+func (s *FileStore) Last(ctx context.Context, teamID string, n int) ([]Entry, error) {
+	return last(ctx, s, teamID, n)
+}
+
+// Findings returns the dates of the findings of the team.
+func (s *FileStore) Findings(ctx context.Context, teamID string) (map[string]vulcan.FindingDates, error) {
+	return loadFindings(ctx, s, teamID)
+}
+
+// SaveFindings replaces the dates of the findings of the team.
+func (s *FileStore) SaveFindings(ctx context.Context, teamID string, dates map[string]vulcan.FindingDates) error {
+	return saveFindings(ctx, s, teamID, dates)
+}
+
+func (s *FileStore) read(ctx context.Context, name string) ([]byte, error) {
+	content, err := os.ReadFile(filepath.Join(s.Dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return content, err
+}
+
+func (s *FileStore) write(ctx context.Context, name string, content []byte) error {
+	if err := os.MkdirAll(s.Dir, 0700); err != nil {
+		return err
+	}
+
+	// The file is replaced atomically, so the history is not lost if the
+	// process is interrupted.
+	tmp, err := os.CreateTemp(s.Dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(s.Dir, name))
+}
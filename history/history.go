@@ -0,0 +1,210 @@
+// Package history stores the summary metrics of the scans of every team and
+// the dates when their findings were found, so the reports can show how they
+// evolve over time.
+package history
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/adevinta/security-overview/vulcan"
+)
+
+const (
+	// TypeFile identifies the store backed by a local directory.
+	TypeFile = "file"
+	// TypeS3 identifies the store backed by a S3 bucket.
+	TypeS3 = "s3"
+)
+
+// Entry contains the summary metrics of a scan.
+type Entry struct {
+	ScanID           string         `json:"scan_id"`
+	Date             string         `json:"date"` // YYYY-MM-DD
+	Risk             int            `json:"risk"`
+	Assets           int            `json:"assets"`
+	VulnerableAssets int            `json:"vulnerable_assets"`
+	Vulnerabilities  map[string]int `json:"vulnerabilities"` // Number of vulnerabilities per impact.
+}
+
+// NewEntry returns the entry with the summary metrics of the given report
+// data.
+func NewEntry(rd *vulcan.ReportData) Entry {
+	vulns := make(map[string]int)
+	for _, v := range rd.VulnerabilitiesPerImpact {
+		// The fraction added to the number of vulnerabilities to be able
+		// to draw the pie charts is discarded.
+		vulns[v.Impact] = int(v.Vulnerabilities)
+	}
+	return Entry{
+		ScanID:           rd.ScanID,
+		Date:             rd.Date,
+		Risk:             int(rd.Risk),
+		Assets:           len(rd.Assets),
+		VulnerableAssets: rd.NumberOfVulnerableAssets,
+		Vulnerabilities:  vulns,
+	}
+}
+
+// Store defines the methods required to store the history of the teams.
+type Store interface {
+	// Record adds the entry to the history of the team, replacing the
+	// previous entry of the same scan, if any.
+	Record(ctx context.Context, teamID string, e Entry) error
+	// Last returns the last n entries of the history of the team, sorted by
+	// date. A non positive n means all the entries.
+	Last(ctx context.Context, teamID string, n int) ([]Entry, error)
+	// Findings returns the dates of the findings of the team, indexed by
+	// the key of the finding, as returned by vulcan.FindingKey.
+	Findings(ctx context.Context, teamID string) (map[string]vulcan.FindingDates, error)
+	// SaveFindings replaces the dates of the findings of the team.
+	SaveFindings(ctx context.Context, teamID string, dates map[string]vulcan.FindingDates) error
+}
+
+// backend defines the methods required to read and write the documents
+// containing the history of the teams.
+type backend interface {
+	// read returns the content of the document, or nil if it does not
+	// exist.
+	read(ctx context.Context, name string) ([]byte, error)
+	write(ctx context.Context, name string, content []byte) error
+}
+
+// index is the content of the JSON document containing the history of a
+// team. The entries are sorted by date.
+type index struct {
+	Entries []Entry `json:"entries"`
+}
+
+func (idx *index) add(e Entry) {
+	entries := idx.Entries[:0]
+	for _, prev := range idx.Entries {
+		if prev.ScanID != e.ScanID {
+			entries = append(entries, prev)
+		}
+	}
+	entries = append(entries, e)
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Date < entries[j].Date
+	})
+	idx.Entries = entries
+}
+
+// Merge returns the last n entries of the given history, sorted by date, after
+// adding the entry to it, replacing the previous entry of the same scan, if
+// any. A non positive n means all the entries. The history is not modified,
+// so the charts of a report can be drawn before the entry is recorded.
+func Merge(entries []Entry, e Entry, n int) []Entry {
+	idx := index{Entries: append([]Entry(nil), entries...)}
+	idx.add(e)
+	return idx.last(n)
+}
+
+func (idx index) last(n int) []Entry {
+	if n <= 0 || n >= len(idx.Entries) {
+		return idx.Entries
+	}
+	return idx.Entries[len(idx.Entries)-n:]
+}
+
+// findings is the content of the JSON document containing the dates of the
+// findings of a team, indexed by the key of the finding.
+type findings struct {
+	Findings map[string]vulcan.FindingDates `json:"findings"`
+}
+
+func record(ctx context.Context, b backend, teamID string, e Entry) error {
+	var idx index
+	if err := readDocument(ctx, b, teamKey(teamID)+".json", &idx); err != nil {
+		return err
+	}
+	idx.add(e)
+	return writeDocument(ctx, b, teamKey(teamID)+".json", idx)
+}
+
+func last(ctx context.Context, b backend, teamID string, n int) ([]Entry, error) {
+	var idx index
+	if err := readDocument(ctx, b, teamKey(teamID)+".json", &idx); err != nil {
+		return nil, err
+	}
+	return idx.last(n), nil
+}
+
+func loadFindings(ctx context.Context, b backend, teamID string) (map[string]vulcan.FindingDates, error) {
+	var f findings
+	if err := readDocument(ctx, b, teamKey(teamID)+"-findings.json", &f); err != nil {
+		return nil, err
+	}
+	if f.Findings == nil {
+		f.Findings = make(map[string]vulcan.FindingDates)
+	}
+	return f.Findings, nil
+}
+
+func saveFindings(ctx context.Context, b backend, teamID string, dates map[string]vulcan.FindingDates) error {
+	return writeDocument(ctx, b, teamKey(teamID)+"-findings.json", findings{Findings: dates})
+}
+
+// UpdateFindings returns the dates of the findings once the findings
+// identified by the given keys, as returned by vulcan.FindingKey, are found
+// on the given date. If prune is true, the findings not found anymore are
+// removed, so they are considered new if they are found again. The given
+// dates are not modified, so they can be saved only after the report is
+// published.
+func UpdateFindings(dates map[string]vulcan.FindingDates, date string, keys []string, prune bool) map[string]vulcan.FindingDates {
+	updated := make(map[string]vulcan.FindingDates, len(dates))
+	for key, d := range dates {
+		updated[key] = d
+	}
+
+	found := make(map[string]bool)
+	for _, key := range keys {
+		found[key] = true
+		d, ok := updated[key]
+		if !ok || date < d.FirstSeen {
+			d.FirstSeen = date
+		}
+		if date > d.LastSeen {
+			d.LastSeen = date
+		}
+		updated[key] = d
+	}
+	if prune {
+		// Only the findings last seen before the scan are removed, so
+		// regenerating the report of an older scan does not remove them.
+		for key, d := range updated {
+			if !found[key] && d.LastSeen < date {
+				delete(updated, key)
+			}
+		}
+	}
+	return updated
+}
+
+func readDocument(ctx context.Context, b backend, name string, v interface{}) error {
+	content, err := b.read(ctx, name)
+	if err != nil || content == nil {
+		return err
+	}
+	if err := json.Unmarshal(content, v); err != nil {
+		return fmt.Errorf("invalid history %s: %w", name, err)
+	}
+	return nil
+}
+
+func writeDocument(ctx context.Context, b backend, name string, v interface{}) error {
+	content, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return b.write(ctx, name, content)
+}
+
+// teamKey returns the name used to store the history of a team, so the team
+// IDs do not need to be valid file names or object keys.
+func teamKey(teamID string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(teamID)))
+}
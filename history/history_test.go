@@ -0,0 +1,205 @@
+package history
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/adevinta/security-overview/vulcan"
+)
+
+func TestMerge(t *testing.T) {
+	entries := []Entry{
+		{ScanID: "s1", Date: "2022-10-01", Risk: 1},
+		{ScanID: "s2", Date: "2022-10-08", Risk: 2},
+		{ScanID: "s3", Date: "2022-10-15", Risk: 3},
+	}
+
+	tests := []struct {
+		name  string
+		entry Entry
+		n     int
+		want  []string // IDs of the scans.
+	}{
+		{name: "new scan", entry: Entry{ScanID: "s4", Date: "2022-10-22"}, want: []string{"s1", "s2", "s3", "s4"}},
+		{name: "last scans", entry: Entry{ScanID: "s4", Date: "2022-10-22"}, n: 2, want: []string{"s3", "s4"}},
+		{name: "more scans than entries", entry: Entry{ScanID: "s4", Date: "2022-10-22"}, n: 10, want: []string{"s1", "s2", "s3", "s4"}},
+		{name: "older scan", entry: Entry{ScanID: "s0", Date: "2022-09-24"}, n: 2, want: []string{"s2", "s3"}},
+		{name: "same scan", entry: Entry{ScanID: "s2", Date: "2022-10-08", Risk: 4}, want: []string{"s1", "s2", "s3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := append([]Entry(nil), entries...)
+			got := Merge(entries, tt.entry, tt.n)
+
+			var ids []string
+			for _, e := range got {
+				ids = append(ids, e.ScanID)
+				if e.ScanID == tt.entry.ScanID && !reflect.DeepEqual(e, tt.entry) {
+					t.Errorf("unexpected entry: got %+v, want %+v", e, tt.entry)
+				}
+			}
+			if !reflect.DeepEqual(ids, tt.want) {
+				t.Errorf("unexpected scans: got %v, want %v", ids, tt.want)
+			}
+			if !reflect.DeepEqual(entries, original) {
+				t.Errorf("history modified: %+v", entries)
+			}
+		})
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	ctx := context.Background()
+	s := &FileStore{Dir: t.TempDir()}
+
+	entries, err := s.Last(ctx, "team", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("unexpected entries of a new team: %+v", entries)
+	}
+
+	for _, e := range []Entry{
+		{ScanID: "s2", Date: "2022-10-08"},
+		{ScanID: "s1", Date: "2022-10-01"},
+		{ScanID: "s3", Date: "2022-10-15"},
+		{ScanID: "s2", Date: "2022-10-08", Risk: 2},
+	} {
+		if err := s.Record(ctx, "team", e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Record(ctx, "other team", Entry{ScanID: "s4", Date: "2022-10-22"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		teamID string
+		n      int
+		want   []Entry
+	}{
+		{
+			teamID: "team",
+			want: []Entry{
+				{ScanID: "s1", Date: "2022-10-01"},
+				{ScanID: "s2", Date: "2022-10-08", Risk: 2},
+				{ScanID: "s3", Date: "2022-10-15"},
+			},
+		},
+		{
+			teamID: "team",
+			n:      1,
+			want:   []Entry{{ScanID: "s3", Date: "2022-10-15"}},
+		},
+		{
+			teamID: "other team",
+			want:   []Entry{{ScanID: "s4", Date: "2022-10-22"}},
+		},
+	}
+	for _, tt := range tests {
+		got, err := s.Last(ctx, tt.teamID, tt.n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("unexpected entries of %s: got %+v, want %+v", tt.teamID, got, tt.want)
+		}
+	}
+}
+
+func TestUpdateFindings(t *testing.T) {
+	dates := map[string]vulcan.FindingDates{
+		"open":       {FirstSeen: "2022-10-01", LastSeen: "2022-10-08"},
+		"fixed":      {FirstSeen: "2022-10-01", LastSeen: "2022-10-08"},
+		"suppressed": {FirstSeen: "2022-10-01", LastSeen: "2022-10-08"},
+		// Found in a scan after the one of the report.
+		"later": {FirstSeen: "2022-10-22", LastSeen: "2022-10-22"},
+	}
+
+	tests := []struct {
+		name  string
+		date  string
+		keys  []string
+		prune bool
+		want  map[string]vulcan.FindingDates
+	}{
+		{
+			name: "without prune",
+			date: "2022-10-15",
+			keys: []string{"open", "suppressed", "new"},
+			want: map[string]vulcan.FindingDates{
+				"open":       {FirstSeen: "2022-10-01", LastSeen: "2022-10-15"},
+				"fixed":      {FirstSeen: "2022-10-01", LastSeen: "2022-10-08"},
+				"suppressed": {FirstSeen: "2022-10-01", LastSeen: "2022-10-15"},
+				"later":      {FirstSeen: "2022-10-22", LastSeen: "2022-10-22"},
+				"new":        {FirstSeen: "2022-10-15", LastSeen: "2022-10-15"},
+			},
+		},
+		{
+			name:  "with prune",
+			date:  "2022-10-15",
+			keys:  []string{"open", "suppressed", "new"},
+			prune: true,
+			want: map[string]vulcan.FindingDates{
+				"open":       {FirstSeen: "2022-10-01", LastSeen: "2022-10-15"},
+				"suppressed": {FirstSeen: "2022-10-01", LastSeen: "2022-10-15"},
+				"later":      {FirstSeen: "2022-10-22", LastSeen: "2022-10-22"},
+				"new":        {FirstSeen: "2022-10-15", LastSeen: "2022-10-15"},
+			},
+		},
+		{
+			name:  "older scan",
+			date:  "2022-09-24",
+			keys:  []string{"open"},
+			prune: true,
+			want: map[string]vulcan.FindingDates{
+				"open":       {FirstSeen: "2022-09-24", LastSeen: "2022-10-08"},
+				"fixed":      {FirstSeen: "2022-10-01", LastSeen: "2022-10-08"},
+				"suppressed": {FirstSeen: "2022-10-01", LastSeen: "2022-10-08"},
+				"later":      {FirstSeen: "2022-10-22", LastSeen: "2022-10-22"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := make(map[string]vulcan.FindingDates)
+			for k, v := range dates {
+				original[k] = v
+			}
+			got := UpdateFindings(dates, tt.date, tt.keys, tt.prune)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("unexpected dates: got %+v, want %+v", got, tt.want)
+			}
+			if !reflect.DeepEqual(dates, original) {
+				t.Errorf("dates modified: %+v", dates)
+			}
+		})
+	}
+}
+
+func TestFileStoreFindings(t *testing.T) {
+	ctx := context.Background()
+	s := &FileStore{Dir: t.TempDir()}
+
+	got, err := s.Findings(ctx, "team")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Fatalf("unexpected findings of a new team: %+v", got)
+	}
+
+	want := map[string]vulcan.FindingDates{"key": {FirstSeen: "2022-10-01", LastSeen: "2022-10-08"}}
+	if err := s.SaveFindings(ctx, "team", want); err != nil {
+		t.Fatal(err)
+	}
+	got, err = s.Findings(ctx, "team")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected findings: got %+v, want %+v", got, want)
+	}
+}
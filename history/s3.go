@@ -0,0 +1,70 @@
+package history
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"path"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+
+	"github.com/adevinta/security-overview/vulcan"
+)
+
+// S3Store stores the history of every team in JSON objects in a S3 bucket.
+// It is not safe to use it from concurrent processes.
+type S3Store struct {
+	Client s3iface.S3API
+	Bucket string
+	Prefix string
+}
+
+// Record adds the entry to the history of the team.
+func (s *S3Store) Record(ctx context.Context, teamID string, e Entry) error {
+	return record(ctx, s, teamID, e)
+}
+
+// Last returns the last n entries of the history of the team.
+func (s *S3Store) Last(ctx context.Context, teamID string, n int) ([]Entry, error) {
+	return last(ctx, s, teamID, n)
+}
+
+// Findings returns the dates of the findings of the team.
+func (s *S3Store) Findings(ctx context.Context, teamID string) (map[string]vulcan.FindingDates, error) {
+	return loadFindings(ctx, s, teamID)
+}
+
+// SaveFindings replaces the dates of the findings of the team.
+func (s *S3Store) SaveFindings(ctx context.Context, teamID string, dates map[string]vulcan.FindingDates) error {
+	return saveFindings(ctx, s, teamID, dates)
+}
+
+func (s *S3Store) read(ctx context.Context, name string) ([]byte, error) {
+	out, err := s.Client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(path.Join(s.Prefix, name)),
+	})
+	var aerr awserr.Error
+	if errors.As(err, &aerr) && aerr.Code() == s3.ErrCodeNoSuchKey {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer out.Body.Close()
+	return io.ReadAll(out.Body)
+}
+
+func (s *S3Store) write(ctx context.Context, name string, content []byte) error {
+	_, err := s.Client.PutObjectWithContext(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.Bucket),
+		Key:         aws.String(path.Join(s.Prefix, name)),
+		Body:        bytes.NewReader(content),
+		ContentType: aws.String("application/json"),
+	})
+	return err
+}
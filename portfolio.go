@@ -0,0 +1,130 @@
+package insights
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"log"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"github.com/adevinta/security-overview/config"
+	"github.com/adevinta/security-overview/report"
+	"github.com/adevinta/security-overview/storage"
+	"github.com/adevinta/security-overview/vulcan"
+)
+
+// TeamScan identifies the scan of a team included in a portfolio report.
+type TeamScan struct {
+	TeamID   string
+	TeamName string
+	ScanID   string
+}
+
+// PortfolioReport represents a report merging the scans of several teams.
+type PortfolioReport struct {
+	name      string
+	teams     []TeamScan
+	folder    string
+	URL       string
+	Risk      int
+	conf      config.Config
+	transport *http.Transport
+	progress  vulcan.ProgressFunc
+	private   storage.Storage
+}
+
+// NewPortfolioReport initializes and returns a new PortfolioReport with the
+// given name, for instance the name of the organization, merging the scans
+// of the given teams.
+func NewPortfolioReport(configFile, name string, teams []TeamScan) (*PortfolioReport, error) {
+	if len(teams) == 0 {
+		return nil, fmt.Errorf("no scans in the portfolio")
+	}
+
+	conf, err := config.ReadConfig(configFile)
+	if err != nil {
+		return nil, err
+	}
+
+	transport, err := vulcan.NewHTTPTransport(conf)
+	if err != nil {
+		return nil, err
+	}
+	awsConfig := newAWSConfig(&conf, transport)
+	private, _, err := newStorages(conf, awsConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PortfolioReport{
+		name:      name,
+		teams:     teams,
+		conf:      conf,
+		transport: transport,
+		private:   private,
+	}, nil
+}
+
+// SetStorage sets the storage where the report is published, replacing the
+// private storage defined in the config.
+func (p *PortfolioReport) SetStorage(private storage.Storage) {
+	p.private = private
+}
+
+// SetOffline enables or disables the offline mode of the cache.
+func (p *PortfolioReport) SetOffline(offline bool) {
+	p.conf.Cache.Offline = offline
+}
+
+// SetProgress sets the function the progress of the collection of the data of
+// every scan is reported to. By default it is logged.
+func (p *PortfolioReport) SetProgress(progress vulcan.ProgressFunc) {
+	p.progress = progress
+}
+
+// Generate collects the data of the scans of the teams and publishes the
+// portfolio report in the private storage. The collection of the data of all
+// the scans is bounded by the timeout defined in the config.
+func (p *PortfolioReport) Generate(ctx context.Context) error {
+	source, err := vulcan.NewReportSource(p.conf, p.transport)
+	if err != nil {
+		return err
+	}
+	opts, err := collectOptions(p.conf, p.progress)
+	if err != nil {
+		return err
+	}
+
+	// The timeout bounds the collection of the data of all the scans, not
+	// the publishing of the report.
+	collectCtx, cancel := collectContext(ctx, p.conf)
+	defer cancel()
+
+	var teams []vulcan.TeamReportData
+	for _, t := range p.teams {
+		log.Printf("Getting the data of the scan %s of the team %s...", t.ScanID, t.TeamName)
+		reportData, err := vulcan.GetReportDataFromSource(collectCtx, p.conf, source, t.ScanID, opts)
+		if err != nil {
+			return fmt.Errorf("error getting the scan %s of the team %s: %w", t.ScanID, t.TeamName, err)
+		}
+		teams = append(teams, vulcan.TeamReportData{TeamID: t.TeamID, TeamName: t.TeamName, Data: reportData})
+	}
+	portfolio := vulcan.NewPortfolio(teams)
+
+	// The folder has the same format as the one of the reports of the
+	// teams: hex(sha256(name))/YYYY-MM-DD
+	date := time.Now().UTC().Format("2006-01-02")
+	p.folder = filepath.Join(fmt.Sprintf("%x", sha256.Sum256([]byte(p.name))), date)
+
+	p.URL, err = report.GeneratePortfolioReport(ctx, p.conf, p.private, p.folder, p.name, date, portfolio)
+	if err != nil {
+		return err
+	}
+	p.Risk = int(portfolio.Risk)
+
+	log.Printf("portfolio report: %v", p.URL)
+
+	return nil
+}
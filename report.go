@@ -1,22 +1,28 @@
 package insights
 
 import (
-	"bytes"
+	"context"
 	"crypto/sha256"
 	"encoding/json"
 	"fmt"
 	"log"
-	"mime"
+	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
 
 	"github.com/adevinta/security-overview/config"
+	"github.com/adevinta/security-overview/history"
 	"github.com/adevinta/security-overview/report"
+	"github.com/adevinta/security-overview/storage"
 	"github.com/adevinta/security-overview/vulcan"
+	"github.com/adevinta/security-overview/vulcan/client"
 )
 
 // DetailedReport represents a detailed report, with an HTML email and a full
@@ -31,42 +37,236 @@ type DetailedReport struct {
 	Risk      int
 	conf      config.Config
 	awsConfig *aws.Config
+	transport *http.Transport
+	progress  vulcan.ProgressFunc
+	private   storage.Storage
+	public    storage.Storage
+
+	compareScan string
 }
 
-// NewDetailedReport  initializes and returns a new DetailedReport
-func NewDetailedReport(configFile, teamName, scanID, teamID string) (*DetailedReport, error) {
+// NewDetailedReport  initializes and returns a new DetailedReport. It fails if
+// the context is done, and the report is generated with the context given to
+// Generate.
+func NewDetailedReport(ctx context.Context, configFile, teamName, scanID, teamID string) (*DetailedReport, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	conf, err := config.ReadConfig(configFile)
 	if err != nil {
 		return nil, err
 	}
 
+	// All the outbound requests, to Vulcan and to S3, share the same
+	// transport.
+	transport, err := vulcan.NewHTTPTransport(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	awsConfig := newAWSConfig(&conf, transport)
+
+	private, public, err := newStorages(conf, awsConfig)
+	if err != nil {
+		return nil, err
+	}
+
 	detailedReport := &DetailedReport{
-		teamName: teamName,
-		scanID:   scanID,
-		teamID:   teamID,
-		conf:     conf,
+		teamName:  teamName,
+		scanID:    scanID,
+		teamID:    teamID,
+		conf:      conf,
+		transport: transport,
+		awsConfig: awsConfig,
+		private:   private,
+		public:    public,
 	}
 
+	return detailedReport, nil
+}
+
+// newAWSConfig returns the config of the AWS clients defined in the given
+// config, setting its default region.
+func newAWSConfig(conf *config.Config, transport *http.Transport) *aws.Config {
 	// Set default region for AWS config.
 	if conf.S3.Region == "" {
 		conf.S3.Region = "eu-west-1"
 	}
-	detailedReport.awsConfig = aws.NewConfig().WithRegion(conf.S3.Region).WithMaxRetries(3)
+	// The AWS SDK requires the transport of the client to be an
+	// *http.Transport to apply the CA bundle defined in its environment, so
+	// the User-Agent is added by newSession.
+	awsConfig := aws.NewConfig().WithRegion(conf.S3.Region).WithMaxRetries(3).WithHTTPClient(&http.Client{Transport: transport})
 	if conf.S3.Endpoint != "" {
-		detailedReport.awsConfig.WithEndpoint(conf.S3.Endpoint).WithS3ForcePathStyle(conf.S3.PathStyle)
+		awsConfig.WithEndpoint(conf.S3.Endpoint).WithS3ForcePathStyle(conf.S3.PathStyle)
 	}
+	return awsConfig
+}
 
-	return detailedReport, nil
+// newStorages returns the storages of the private and the public files of the
+// reports defined in the config.
+func newStorages(conf config.Config, awsConfig *aws.Config) (private, public storage.Storage, err error) {
+	switch conf.Storage.Type {
+	case "", storage.TypeS3:
+		sess, err := newSession(awsConfig)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := storage.CheckEncoding(conf.S3.ContentEncoding); err != nil {
+			return nil, nil, err
+		}
+		// Both storages share the session and the options of the uploads.
+		client := s3.New(sess)
+		s3Private := newS3Storage(conf, client, conf.S3.PrivateBucket, privateURL(conf))
+		// Only the files of the private bucket are pre-signed.
+		s3Private.Expiry = conf.S3.PresignExpiry
+		private = s3Private
+		public = newS3Storage(conf, client, conf.S3.PublicBucket, publicURL(conf))
+	case storage.TypeDir:
+		dir := conf.Storage.Dir
+		if dir == "" {
+			dir = conf.General.LocalTempDir
+		}
+		private = &storage.FileStorage{Dir: filepath.Join(dir, conf.S3.PrivateBucket), BaseURL: dirURL(conf.Storage.BaseURL, conf.S3.PrivateBucket)}
+		public = &storage.FileStorage{Dir: filepath.Join(dir, conf.S3.PublicBucket), BaseURL: dirURL(conf.Storage.BaseURL, conf.S3.PublicBucket)}
+	case storage.TypeMemory:
+		private = &storage.MemStorage{BaseURL: privateURL(conf)}
+		public = &storage.MemStorage{BaseURL: publicURL(conf)}
+	default:
+		return nil, nil, fmt.Errorf("unknown storage type: %s", conf.Storage.Type)
+	}
+	return private, public, nil
 }
 
-// GenerateLocalFiles grabs data for a fiven scan ID from Vulcan Core and saves
-// the HTML enauk and the  full report in a local folder
-func (d *DetailedReport) GenerateLocalFiles() error {
+// newS3Storage returns the storage of the given bucket with the options of the
+// uploads defined in the config.
+func newS3Storage(conf config.Config, client s3iface.S3API, bucket, baseURL string) *storage.S3Storage {
+	return &storage.S3Storage{
+		Client:          client,
+		Bucket:          bucket,
+		BaseURL:         baseURL,
+		PartSize:        conf.S3.PartSizeMB * 1024 * 1024,
+		Concurrency:     conf.S3.Concurrency,
+		CacheControl:    conf.S3.CacheControl,
+		ContentEncoding: conf.S3.ContentEncoding,
+		SSE:             conf.S3.SSE,
+		SSEKMSKeyID:     conf.S3.SSEKMSKeyID,
+	}
+}
+
+// privateURL returns the URL the files stored in the private bucket are
+// published under: the base URL of the bucket defined in the config or, by
+// default, the proxy.
+func privateURL(conf config.Config) string {
+	if conf.S3.PrivateBaseURL != "" {
+		return conf.S3.PrivateBaseURL
+	}
+	if conf.Proxy.Endpoint != "" {
+		return conf.Proxy.Endpoint
+	}
+	return storage.S3BucketURL(conf.S3.Region, conf.S3.Endpoint, conf.S3.PrivateBucket, conf.S3.PathStyle)
+}
+
+// publicURL returns the URL the files stored in the public bucket are
+// published under: the base URL of the bucket defined in the config or, by
+// default, the URL of the bucket itself.
+func publicURL(conf config.Config) string {
+	if conf.S3.PublicBaseURL != "" {
+		return conf.S3.PublicBaseURL
+	}
+	return storage.S3BucketURL(conf.S3.Region, conf.S3.Endpoint, conf.S3.PublicBucket, conf.S3.PathStyle)
+}
+
+// dirURL returns the URL the files stored in the directory of the given
+// bucket are published under, or an empty string if the directory is not
+// published.
+func dirURL(baseURL, bucket string) string {
+	if baseURL == "" {
+		return ""
+	}
+	return strings.TrimSuffix(baseURL, "/") + "/" + bucket
+}
+
+// SetStorage sets the storages where the private and the public files of the
+// reports are published, replacing the ones defined in the config.
+func (d *DetailedReport) SetStorage(private, public storage.Storage) {
+	d.private = private
+	d.public = public
+}
+
+// SetOffline enables or disables the offline mode of the cache. In offline
+// mode the data of the scan is only read from the cache and the generation
+// fails if it is not cached.
+func (d *DetailedReport) SetOffline(offline bool) {
+	d.conf.Cache.Offline = offline
+}
+
+// SetProgress sets the function the progress of the collection of the data of
+// the scan is reported to. By default it is logged.
+func (d *DetailedReport) SetProgress(progress vulcan.ProgressFunc) {
+	d.progress = progress
+}
+
+// SetCompareScan sets the scan the report is compared with, so it includes
+// the findings that are new, fixed or still open since that scan. It can be
+// either the ID of the scan or the path to the JSON file with its report
+// data.
+func (d *DetailedReport) SetCompareScan(scan string) {
+	d.compareScan = scan
+}
+
+// Generate grabs data for a fiven scan ID from Vulcan Core, saves the HTML
+// email in a local folder and publishes the full report in the storages. The
+// collection of the data is bounded by the context and by the timeout defined
+// in the config.
+func (d *DetailedReport) Generate(ctx context.Context) error {
+	// Only the collection of the data is bounded by the timeout, not the
+	// publishing of the reports.
+	collectCtx, cancel := collectContext(ctx, d.conf)
+	defer cancel()
+
 	// Grabs scan data on Vulcan Core
-	reportData, err := vulcan.GetReportData(d.conf, d.scanID)
+	source, err := vulcan.NewReportSource(d.conf, d.transport)
+	if err != nil {
+		return err
+	}
+	groupieDB, err := d.openGroupieDB()
+	if err != nil {
+		return err
+	}
+	opts, err := collectOptions(d.conf, d.progress)
+	if err != nil {
+		return err
+	}
+	if groupieDB != nil {
+		opts.DB = groupieDB
+	}
+	reportData, err := vulcan.GetReportDataFromSource(collectCtx, d.conf, source, d.scanID, opts)
+	if err != nil {
+		return err
+	}
+
+	// Update the dates of the findings of the team, so the report shows for
+	// how long they are open. They are only saved once the reports are
+	// published.
+	store, err := d.historyStore()
 	if err != nil {
 		return err
 	}
+	var findingDates map[string]vulcan.FindingDates
+	if store != nil {
+		findingDates, err = d.updateFindings(ctx, store, reportData)
+		if err != nil {
+			return err
+		}
+	}
+
+	if d.compareScan != "" {
+		previous, err := d.previousReportData(collectCtx, source)
+		if err != nil {
+			return fmt.Errorf("error getting the scan to compare with: %w", err)
+		}
+		reportData.Diff = vulcan.DiffScans(reportData, previous)
+	}
 
 	file, err := os.Create(d.teamName + ".json")
 	if err != nil {
@@ -87,59 +287,214 @@ func (d *DetailedReport) GenerateLocalFiles() error {
 	sha := fmt.Sprintf("%x", sha256.Sum256([]byte(d.teamName)))
 	d.folder = filepath.Join(sha, reportData.Date)
 
-	// Remove previously generated files and folders and then recreate them.
-	err = d.cleanLocalFolders()
+	// Remove the previously generated overview.
+	err = d.cleanLocalFolder()
 	if err != nil {
 		return err
 	}
 
-	// Generate files for the Overview. The files will be stored in this way:
-	// <scan-id>/
-	//	  '
-	//    '--<scan-id>-overview.html
-	//	  '
-	//    '--<public-bucket>/
-	//	         '
-	//           '--<hex(sha256(teamName))>/
-	//	                '
-	//                  '--<YYYY-MM-DD>/
-	//                         '
-	//                         '--<Most Vulnerable Assets>.png
-	//                         '
-	//                         '--<Impact Distribution>.png
-	d.Email, err = report.GenerateOverview(d.conf, d.awsConfig, d.folder, reportData, d.teamName, d.teamID, d.scanID)
+	// Retrieve the last scans of the team, including this one, to draw the
+	// historical charts. The scan is only recorded in the history once the
+	// reports are published.
+	entry := history.NewEntry(reportData)
+	hist, err := d.lastHistory(ctx, store, entry)
 	if err != nil {
 		return err
 	}
 
-	// Generate files for the Full Report. The files will be stored in this way:
-	// <scan-id>/
+	// Generate files for the Full Report. The files will be published in the
+	// private storage in this way:
+	// <hex(sha256(teamName))>/
 	//    '
-	//    '--<private-bucket>/
+	//    '--<YYYY-MM-DD>/
+	//           '
+	//           '--<scan-id>-full-report.html
 	//           '
-	//           '--<hex(sha256(teamName))>/
-	//                  '
-	//                  '--<YYYY-MM-DD>/
-	//                         '
-	//                         '--<scan-id>-full-report.html
-	//                         '
-	//                         '--<script>.js
+	//           '--<script>.js
 	//
 	// The result will be the the URL in which the Full Report will be available.
-	// The Overview HTML will be generated pointing to this link.
-	d.URL, err = report.GenerateFullReport(d.conf, d.awsConfig, d.folder, reportData, d.teamName)
+	// If the URLs are signed the Overview HTML will link to it.
+	d.URL, err = report.GenerateFullReport(ctx, d.conf, d.private, d.folder, reportData, d.teamName)
 	if err != nil {
 		return err
 	}
 
+	link := ""
+	if d.conf.S3.PresignExpiry > 0 {
+		link = d.URL
+	}
+
+	// Generate files for the Overview. The HTML is written to
+	// <local-temp-dir>/<scan-id>/<scan-id>-overview.html and the charts are
+	// published in the public storage in this way:
+	// <hex(sha256(teamName))>/
+	//    '
+	//    '--<YYYY-MM-DD>/
+	//           '
+	//           '--<Most Vulnerable Assets>.png
+	//           '
+	//           '--<Impact Distribution>.png
+	//           '
+	//           '--<Vulnerable Assets History>.png
+	//           '
+	//           '--<Highest Impact History>.png
+	d.Email, err = report.GenerateOverview(ctx, d.conf, d.public, d.localStorage(), d.folder, reportData, hist, d.teamName, d.teamID, d.scanID, link)
+	if err != nil {
+		return err
+	}
+
+	if store != nil {
+		if err := store.Record(ctx, d.teamID, entry); err != nil {
+			return err
+		}
+		if err := store.SaveFindings(ctx, d.teamID, findingDates); err != nil {
+			return err
+		}
+	}
+	// The groups of the findings are only persisted once the reports showing
+	// them are published.
+	if groupieDB != nil {
+		if err := groupieDB.Save(); err != nil {
+			return err
+		}
+	}
+
 	d.Risk = int(reportData.Risk)
 
+	log.Printf("overview: %v", d.Email)
+	log.Printf("full report: %v", d.URL)
+
 	return nil
 }
 
-// GenerateLocalFilesFromCheck grabs the check report stored in a file
-// and generates the html report using that unique check report.
-func (d *DetailedReport) GenerateLocalFilesFromCheck(path string) error {
+// collectOptions returns the options of the collection of the data of the
+// scans defined in the config. The assets are enriched with the inventory
+// before the policy is applied, so its rules can match their metadata.
+func collectOptions(conf config.Config, progress vulcan.ProgressFunc) (vulcan.Options, error) {
+	opts := vulcan.Options{Progress: progress}
+	var err error
+	if conf.Suppressions.File != "" {
+		opts.Suppressions, err = vulcan.ReadSuppressions(conf.Suppressions.File)
+		if err != nil {
+			return vulcan.Options{}, fmt.Errorf("error reading the suppressions: %w", err)
+		}
+	}
+	if conf.Policy.File != "" {
+		opts.Policy, err = vulcan.ReadPolicy(conf.Policy.File)
+		if err != nil {
+			return vulcan.Options{}, fmt.Errorf("error reading the policy: %w", err)
+		}
+	}
+	if conf.Inventory.File != "" {
+		inv, err := vulcan.ReadInventory(conf.Inventory.File)
+		if err != nil {
+			return vulcan.Options{}, err
+		}
+		opts.Inventory = inv
+	}
+	return opts, nil
+}
+
+// collectContext returns a copy of the context bounded by the timeout of the
+// collection of the data of the scans defined in the config, if any.
+func collectContext(ctx context.Context, conf config.Config) (context.Context, context.CancelFunc) {
+	if conf.General.Timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, conf.General.Timeout)
+}
+
+// previousReportData returns the report data of the scan the report is
+// compared with, read from a file or collected from the given source with the
+// same suppressions, policy and inventory as the current scan.
+func (d *DetailedReport) previousReportData(ctx context.Context, source vulcan.ReportSource) (*vulcan.ReportData, error) {
+	if info, err := os.Stat(d.compareScan); err == nil && !info.IsDir() {
+		return vulcan.ReadReportData(d.compareScan)
+	}
+	// The suppressions, the policy and the inventory also apply to the
+	// previous scan, so they are not reported as changes. The grouping
+	// database is not updated with it.
+	opts, err := collectOptions(d.conf, nil)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Getting the data of the scan %s to compare with...", d.compareScan)
+	return vulcan.GetReportDataFromSource(ctx, d.conf, source, d.compareScan, opts)
+}
+
+// openGroupieDB opens the grouping database of the team defined in the
+// config. It returns nil if the database is kept in memory.
+func (d *DetailedReport) openGroupieDB() (*vulcan.FileDB, error) {
+	switch d.conf.Groupie.Type {
+	case "", vulcan.GroupieMemory:
+		return nil, nil
+	case vulcan.GroupieFile:
+		name := fmt.Sprintf("%x.gob", sha256.Sum256([]byte(d.teamID)))
+		return vulcan.OpenFileDB(filepath.Join(d.conf.Groupie.Dir, name), d.conf.Groupie.Keep)
+	default:
+		return nil, fmt.Errorf("unknown groupie type: %s", d.conf.Groupie.Type)
+	}
+}
+
+// historyStore returns the store of the history of the teams defined in the
+// config, or nil if the history is disabled.
+func (d *DetailedReport) historyStore() (history.Store, error) {
+	switch d.conf.History.Type {
+	case "":
+		return nil, nil
+	case history.TypeFile:
+		return &history.FileStore{Dir: d.conf.History.Dir}, nil
+	case history.TypeS3:
+		sess, err := newSession(d.awsConfig)
+		if err != nil {
+			return nil, err
+		}
+		return &history.S3Store{Client: s3.New(sess), Bucket: d.conf.History.Bucket, Prefix: d.conf.History.Prefix}, nil
+	default:
+		return nil, fmt.Errorf("unknown history type: %s", d.conf.History.Type)
+	}
+}
+
+// updateFindings returns the dates of the findings of the team once the
+// findings of the scan are found, and sets them in the report data. The
+// suppressed findings are also considered found, so their dates are kept. The
+// findings not found anymore are only forgotten if no check is missing from
+// the scan.
+func (d *DetailedReport) updateFindings(ctx context.Context, store history.Store, reportData *vulcan.ReportData) (map[string]vulcan.FindingDates, error) {
+	keys := make([]string, 0, len(reportData.Vulnerabilities)+len(reportData.Suppressed))
+	for _, v := range reportData.Vulnerabilities {
+		keys = append(keys, vulcan.FindingKey(v))
+	}
+	for _, s := range reportData.Suppressed {
+		keys = append(keys, vulcan.FindingKey(s.Vulnerability))
+	}
+	dates, err := store.Findings(ctx, d.teamID)
+	if err != nil {
+		return nil, err
+	}
+	prune := len(reportData.MissingChecks) == 0
+	dates = history.UpdateFindings(dates, reportData.Date, keys, prune)
+	reportData.SetFindingDates(dates, d.conf.SLA)
+	return dates, nil
+}
+
+// lastHistory returns the last scans of the team, as many as defined in the
+// config, once the given entry is added to them, without recording it. It
+// returns no scans if the history is disabled.
+func (d *DetailedReport) lastHistory(ctx context.Context, store history.Store, entry history.Entry) ([]history.Entry, error) {
+	if store == nil {
+		return nil, nil
+	}
+	entries, err := store.Last(ctx, d.teamID, 0)
+	if err != nil {
+		return nil, err
+	}
+	return history.Merge(entries, entry, d.conf.History.Scans), nil
+}
+
+// GenerateFromCheck grabs the check report stored in a file and publishes
+// the html report generated using that unique check report.
+func (d *DetailedReport) GenerateFromCheck(ctx context.Context, path string) error {
 
 	reportData, err := vulcan.GetReportDataFromFile(d.conf, d.scanID, path)
 	if err != nil {
@@ -165,115 +520,73 @@ func (d *DetailedReport) GenerateLocalFilesFromCheck(path string) error {
 	sha := fmt.Sprintf("%x", sha256.Sum256([]byte(d.teamName)))
 	d.folder = filepath.Join(sha, reportData.Date)
 
-	// Remove previously generated files and folders and then recreate them.
-	err = d.cleanLocalFolders()
-	if err != nil {
-		return err
-	}
-
-	// Generate files for the Full Report. The files will be stored in this way:
-	// <scan-id>/
+	// Generate files for the Full Report. The files will be published in the
+	// private storage in this way:
+	// <hex(sha256(teamName))>/
 	//    '
-	//    '--<private-bucket>/
+	//    '--<YYYY-MM-DD>/
+	//           '
+	//           '--<scan-id>-full-report.html
 	//           '
-	//           '--<hex(sha256(teamName))>/
-	//                  '
-	//                  '--<YYYY-MM-DD>/
-	//                         '
-	//                         '--<scan-id>-full-report.html
-	//                         '
-	//                         '--<script>.js
+	//           '--<script>.js
 	//
 	// The result will be the the URL in which the Full Report will be available.
 	// The Overview HTML will be generated pointing to this link.
-	d.URL, err = report.GenerateFullReport(d.conf, d.awsConfig, d.folder, reportData, d.teamName)
+	d.URL, err = report.GenerateFullReport(ctx, d.conf, d.private, d.folder, reportData, d.teamName)
 	if err != nil {
 		return err
 	}
 
 	d.Risk = int(reportData.Risk)
 
+	log.Printf("full report: %v", d.URL)
+
 	return nil
 }
 
-func (d *DetailedReport) cleanLocalFolders() error {
-	err := os.RemoveAll(filepath.Join(d.conf.General.LocalTempDir, d.scanID))
-	if err != nil {
-		return err
-	}
-
-	err = os.MkdirAll(filepath.Join(d.conf.General.LocalTempDir, d.scanID, d.conf.S3.PublicBucket, d.folder), 0700)
-	if err != nil {
-		return err
-	}
-
-	err = os.MkdirAll(filepath.Join(d.conf.General.LocalTempDir, d.scanID, d.conf.S3.PrivateBucket, d.folder), 0700)
-	if err != nil {
-		return err
-	}
+// GenerateLocalFiles generates and publishes the report.
+//
+// Deprecated: Use Generate, which can be canceled. The reports are published
+// as they are generated, so UploadFilesToS3 is no longer needed.
+func (d *DetailedReport) GenerateLocalFiles() error {
+	return d.Generate(context.Background())
+}
 
-	return nil
+// GenerateLocalFilesFromCheck generates and publishes the report of the check
+// report stored in a file.
+//
+// Deprecated: Use GenerateFromCheck, which can be canceled.
+func (d *DetailedReport) GenerateLocalFilesFromCheck(path string) error {
+	return d.GenerateFromCheck(context.Background(), path)
 }
 
+// UploadFilesToS3 does nothing, as the reports are already published by the
+// methods generating them.
+//
+// Deprecated: Generate and GenerateFromCheck publish the reports.
 func (d *DetailedReport) UploadFilesToS3() error {
-	err := d.uploadBucket(d.conf.S3.PrivateBucket)
-	if err != nil {
-		return err
-	}
-
-	err = d.uploadBucket(d.conf.S3.PublicBucket)
-	if err != nil {
-		return err
-	}
-
-	log.Printf("overview: %v", d.Email)
-	log.Printf("full report: %v", d.URL)
-
+	log.Printf("the reports have already been published")
 	return nil
 }
 
-func (d *DetailedReport) uploadBucket(bucket string) error {
-	localPath := filepath.Join(d.conf.General.LocalTempDir, d.scanID, bucket, d.folder)
-	fd, err := os.Open(localPath)
-	if err != nil {
-		return err
-	}
-	defer fd.Close()
-	files, _ := fd.Readdir(-1)
-	for _, file := range files {
-		log.Printf("upload: %v/%v", bucket, filepath.Join(d.folder, file.Name()))
-		err = d.uploadFile(bucket, filepath.Join(d.folder, file.Name()), localPath, file.Name())
-		if err != nil {
-			return err
-		}
-	}
-	return nil
+// cleanLocalFolder removes the local folder where the overview is written.
+func (d *DetailedReport) cleanLocalFolder() error {
+	return os.RemoveAll(filepath.Join(d.conf.General.LocalTempDir, d.scanID))
 }
 
-func (d *DetailedReport) uploadFile(bucket, key, localPath, filename string) error {
-	sess, err := session.NewSession(d.awsConfig)
-	if err != nil {
-		return err
-	}
-	svc := s3.New(sess)
-	localFilename := filepath.Join(localPath, filename)
-	contentType := mime.TypeByExtension(filepath.Ext(localFilename))
-	body, err := os.ReadFile(localFilename)
-	if err != nil {
-		return err
-	}
-
-	params := &s3.PutObjectInput{
-		Bucket:      aws.String(bucket),
-		Key:         aws.String(key),
-		Body:        bytes.NewReader(body),
-		ContentType: aws.String(contentType),
-	}
+// localStorage returns the storage of the local folder where the overview is
+// written.
+func (d *DetailedReport) localStorage() storage.Storage {
+	return &storage.FileStorage{Dir: filepath.Join(d.conf.General.LocalTempDir, d.scanID)}
+}
 
-	_, err = svc.PutObject(params)
+// newSession returns an AWS session that identifies the tool in the
+// User-Agent of the requests.
+func newSession(awsConfig *aws.Config) (*session.Session, error) {
+	sess, err := session.NewSession(awsConfig)
 	if err != nil {
-		return err
+		return nil, err
 	}
-
-	return nil
+	sess.Handlers.Build.PushBack(request.MakeAddToUserAgentFreeFormHandler(client.UserAgent()))
+	return sess, nil
 }
@@ -2,6 +2,7 @@ package report
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"html/template"
@@ -11,11 +12,11 @@ import (
 	"path/filepath"
 	"strings"
 
-	"github.com/aws/aws-sdk-go/aws"
 	"github.com/microcosm-cc/bluemonday"
 	blackfriday "github.com/russross/blackfriday/v2"
 
 	"github.com/adevinta/security-overview/resources"
+	"github.com/adevinta/security-overview/storage"
 	"github.com/adevinta/security-overview/utils"
 	"github.com/adevinta/security-overview/vulcan"
 	vulcanreport "github.com/adevinta/vulcan-report"
@@ -35,9 +36,11 @@ type VulnsCount struct {
 }
 
 type AssetVulns struct {
-	Asset string                 `json:"asset" xml:"asset"`
-	Count VulnsCount             `json:"vulnerabilities_count" xml:"vulnerabilities_count"`
-	Vulns []vulcan.Vulnerability `json:"vulnerabilities" xml:"vulnerabilities"`
+	Asset    string                 `json:"asset" xml:"asset"`
+	Type     string                 `json:"type" xml:"type"`
+	Metadata vulcan.AssetMetadata   `json:"metadata" xml:"metadata"`
+	Count    VulnsCount             `json:"vulnerabilities_count" xml:"vulnerabilities_count"`
+	Vulns    []vulcan.Vulnerability `json:"vulnerabilities" xml:"vulnerabilities"`
 }
 
 type Group struct {
@@ -47,26 +50,32 @@ type Group struct {
 }
 
 type FullReport struct {
-	Jira                string      `json:"-" xml:"-"`
-	ContactChannel      string      `json:"-" xml:"-"`
-	ContactEmail        string      `json:"-" xml:"-"`
-	LocalTempDir        string      `json:"-" xml:"-"`
-	PublicResourcesPath string      `json:"-" xml:"-"`
-	Bucket              string      `json:"-" xml:"-"`
-	Folder              string      `json:"-" xml:"-"`
-	Filename            string      `json:"-" xml:"-"`
-	Extension           string      `json:"-" xml:"-"`
-	Proxy               string      `json:"-" xml:"-"`
-	UploadToS3          bool        `json:"-" xml:"-"`
-	AWSConfig           *aws.Config `json:"-" xml:"-"`
+	Jira                string          `json:"-" xml:"-"`
+	ContactChannel      string          `json:"-" xml:"-"`
+	ContactEmail        string          `json:"-" xml:"-"`
+	PublicResourcesPath string          `json:"-" xml:"-"`
+	Storage             storage.Storage `json:"-" xml:"-"`
+	Folder              string          `json:"-" xml:"-"`
+	Filename            string          `json:"-" xml:"-"`
+	Extension           string          `json:"-" xml:"-"`
+	Proxy               string          `json:"-" xml:"-"`
+	Uploads             int             `json:"-" xml:"-"`
 
-	Risk                    vulcanreport.SeverityRank `json:"risk" xml:"risk"`
-	ScanID                  string                    `json:"scan_id" xml:"scan_id"`
-	ScanTime                string                    `json:"scan_time" xml:"scan_time"`
-	TeamName                string                    `json:"team_name" xml:"team_name"`
-	Vulnerabilities         int                       `json:"vulnerabilities" xml:"vulnerabilities"`
-	VulnerabilitiesPerAsset []AssetVulns              `json:"assets" xml:"assets"`
-	Groups                  []Group                   `json:"groups" xml:"groups"`
+	Risk                    vulcanreport.SeverityRank  `json:"risk" xml:"risk"`
+	ScanID                  string                     `json:"scan_id" xml:"scan_id"`
+	ScanTime                string                     `json:"scan_time" xml:"scan_time"`
+	TeamName                string                     `json:"team_name" xml:"team_name"`
+	Vulnerabilities         int                        `json:"vulnerabilities" xml:"vulnerabilities"`
+	VulnerabilitiesPerAsset []AssetVulns               `json:"assets" xml:"assets"`
+	Groups                  []Group                    `json:"groups" xml:"groups"`
+	MissingChecks           []vulcan.MissingCheck      `json:"missing_checks" xml:"missing_checks"`
+	Coverage                []vulcan.AssetCoverage     `json:"coverage" xml:"coverage"`
+	Diff                    *vulcan.Diff               `json:"diff,omitempty" xml:"diff,omitempty"`
+	Suppressed              []vulcan.SuppressedFinding `json:"suppressed" xml:"suppressed"`
+	ExpiredSuppressions     []vulcan.SuppressionRule   `json:"expired_suppressions" xml:"expired_suppressions"`
+	Overrides               []vulcan.OverriddenFinding `json:"overrides" xml:"overrides"`
+	AssetBreakdowns         []vulcan.AssetBreakdown    `json:"asset_breakdowns" xml:"asset_breakdowns"`
+	AssetTypes              []vulcan.AssetTypeSummary  `json:"asset_types" xml:"asset_types"`
 
 	GAID string `json:"-" xml:"-"`
 
@@ -79,6 +88,14 @@ type FullReport struct {
 	RoadmapLink       string `json:"-" xml:""`
 }
 
+// changesSection is a group of findings in the section with the changes since
+// the previous scan.
+type changesSection struct {
+	Title string
+	Icon  string
+	Vulns []vulcan.Vulnerability
+}
+
 var templateFuncMap = template.FuncMap{
 	"upload": func(path string) string {
 		panic(fmt.Errorf("upload template func not implemented"))
@@ -140,6 +157,7 @@ var templateFuncMap = template.FuncMap{
 	"roundScore": func(score float32) string {
 		return fmt.Sprintf("%.1f", score)
 	},
+	"rankSeverity": vulcanreport.RankSeverity,
 	"isEmpty": func(text string) bool {
 		t1 := strings.Trim(text, "\n")
 		t1 = strings.Trim(t1, " ")
@@ -153,6 +171,19 @@ var templateFuncMap = template.FuncMap{
 		}
 		return false
 	},
+	"statusToClass": func(status string) string {
+		switch status {
+		case vulcan.StatusFinished:
+			return "is-success"
+		case "FAILED", "ABORTED", "TIMEOUT":
+			return "is-danger"
+		default:
+			return "is-warning"
+		}
+	},
+	"changes": func(title, icon string, vulns []vulcan.Vulnerability) changesSection {
+		return changesSection{Title: title, Icon: icon, Vulns: vulns}
+	},
 	"countGroupVulnerabilities": func(g Group) int {
 		count := 0
 		for _, vuln := range g.Vulns {
@@ -164,67 +195,61 @@ var templateFuncMap = template.FuncMap{
 	},
 }
 
-func (fr *FullReport) Generate() (string, error) {
-	generateFuncs := templateFuncMap
-	generateFuncs["upload"] = func(path string) string {
+// templateFuncs returns a copy of the template functions with the given
+// upload function, so concurrent reports do not share it.
+func templateFuncs(upload func(path string) string) template.FuncMap {
+	funcs := make(template.FuncMap, len(templateFuncMap))
+	for name, f := range templateFuncMap {
+		funcs[name] = f
+	}
+	funcs["upload"] = upload
+	return funcs
+}
+
+// Generate publishes the JSON and the HTML files of the report. The JSON file
+// and the resources are uploaded while the HTML is generated, and the HTML is
+// only published once they are.
+func (fr *FullReport) Generate(ctx context.Context) (string, error) {
+	files := newPublisher(ctx, fr.Storage, fr.Uploads)
+	generateFuncs := templateFuncs(func(path string) string {
 		ext := filepath.Ext(path)
 		body, errUploadFile := resources.Files.ReadFile(path)
 		if errUploadFile != nil {
 			log.Println(errUploadFile)
 			return ""
 		}
-		url, errUploadFile := utils.GenerateLocalFile(body, fr.Proxy, fr.Bucket, fr.Folder, filepath.Join(fr.LocalTempDir, fr.ScanID, fr.Bucket, fr.Folder), "", ext)
-		if errUploadFile != nil {
-			log.Println(errUploadFile)
-		}
-
-		return url
-	}
+		return files.PutFile(fr.Folder, "", ext, body)
+	})
 
 	reportTemplate := template.New("full-report").Funcs(generateFuncs)
-	fullReportJSONURL, fullReportJSONPath, err := GenerateLocalFilePathAndRemoteURL(fr.Proxy, fr.Bucket, fr.Folder, filepath.Join(fr.LocalTempDir, fr.ScanID, fr.Bucket, fr.Folder), fr.Filename, ".json")
-	if err != nil {
-		return "", err
-	}
 
-	file, err := os.Create(fullReportJSONPath)
+	var jsonBuf bytes.Buffer
+	encoder := json.NewEncoder(&jsonBuf)
+	err := encoder.Encode(*fr)
 	if err != nil {
 		return "", err
 	}
-	defer file.Close()
-
-	encoder := json.NewEncoder(file)
-	err = encoder.Encode(*fr)
-	if err != nil {
-		return "", err
-	}
-
-	fr.JSONExportURL = fullReportJSONURL
+	fr.JSONExportURL = files.PutFile(fr.Folder, fr.Filename, ".json", jsonBuf.Bytes())
 
 	log.Println("full report JSON: ", fr.JSONExportURL)
 
 	reportHTML, err := reportTemplate.ParseFS(resources.Files, templateFileFullReport)
 	if err != nil {
+		files.Wait()
 		return "", err
 	}
 
-	reportHTMLURL, reportHTMLPath, err := GenerateLocalFilePathAndRemoteURL(fr.Proxy, fr.Bucket, fr.Folder, filepath.Join(fr.LocalTempDir, fr.ScanID, fr.Bucket, fr.Folder), fr.Filename, fr.Extension)
+	var buf bytes.Buffer
+	err = reportHTML.ExecuteTemplate(&buf, templateFileFullReport, fr)
 	if err != nil {
+		files.Wait()
 		return "", err
 	}
-
-	file, err = os.Create(reportHTMLPath)
-	if err != nil {
+	if err := files.Wait(); err != nil {
 		return "", err
 	}
-	defer file.Close()
 
-	err = reportHTML.ExecuteTemplate(file, templateFileFullReport, fr)
-	if err != nil {
-		return "", err
-	}
-
-	return reportHTMLURL, nil
+	return PutFile(ctx, fr.Storage, fr.Folder, fr.Filename, fr.Extension, buf.Bytes())
 }
 
 func (fr *FullReport) Regenerate() (string, error) {
@@ -233,8 +258,7 @@ func (fr *FullReport) Regenerate() (string, error) {
 	// template.
 	fr.Proxy = "."
 
-	regenerateFuncs := templateFuncMap
-	regenerateFuncs["upload"] = func(relativePath string) string {
+	regenerateFuncs := templateFuncs(func(relativePath string) string {
 		content, err := resources.Files.ReadFile(relativePath)
 		if err != nil {
 			panic(err)
@@ -246,7 +270,7 @@ func (fr *FullReport) Regenerate() (string, error) {
 			panic(err)
 		}
 		return relativePath
-	}
+	})
 
 	reportTemplate := template.New("full-report").Funcs(regenerateFuncs)
 	reportHTML, err := reportTemplate.ParseFS(resources.Files, templateFileFullReport)
@@ -1,13 +1,13 @@
 package report
 
 import (
+	"context"
 	"fmt"
 	"sort"
 	"time"
 
-	"github.com/aws/aws-sdk-go/aws"
-
 	"github.com/adevinta/security-overview/config"
+	"github.com/adevinta/security-overview/storage"
 	"github.com/adevinta/security-overview/vulcan"
 	report "github.com/adevinta/vulcan-report"
 )
@@ -25,8 +25,8 @@ func maxScore(vulns []vulcan.Vulnerability) float32 {
 }
 
 // GenerateFullReport generates the html report suitable to be published as a static web page.
-// Returns the url or the file path, depending on configuration, where the report generated is stored.
-func GenerateFullReport(conf config.Config, awsConfig *aws.Config, folder string, reportData *vulcan.ReportData, teamName string) (string, error) {
+// Returns the url or the file path, depending on the storage, where the report generated is stored.
+func GenerateFullReport(ctx context.Context, conf config.Config, store storage.Storage, folder string, reportData *vulcan.ReportData, teamName string) (string, error) {
 	mapVulnerabilitiesPerAsset := make(map[string][]vulcan.Vulnerability)
 	aggregatedVulnerabilities := []report.Vulnerability{}
 
@@ -58,7 +58,7 @@ func GenerateFullReport(conf config.Config, awsConfig *aws.Config, folder string
 				count.Issues++
 			}
 		}
-		assetVulnsSlice = append(assetVulnsSlice, AssetVulns{Asset: asset, Count: count, Vulns: vulns})
+		assetVulnsSlice = append(assetVulnsSlice, AssetVulns{Asset: asset, Type: reportData.AssetTypes[asset], Metadata: reportData.AssetsMetadata[asset], Count: count, Vulns: vulns})
 		vulnCount += count.Low + count.Medium + count.High
 	}
 
@@ -83,18 +83,16 @@ func GenerateFullReport(conf config.Config, awsConfig *aws.Config, folder string
 		return maxScore(assetVulnsSlice[i].Vulns) > maxScore(assetVulnsSlice[j].Vulns)
 	})
 	fullReport := FullReport{
-		LocalTempDir:    conf.General.LocalTempDir,
 		HomeURL:         conf.Endpoints.VulcanUI,
 		ManageAssetsURL: conf.Endpoints.VulcanUI + ManageAssetsPath,
 		DetailsURL:      conf.Endpoints.VulcanUI + DetailsPath,
 		DashboardURL:    conf.Endpoints.VulcanUI + DashboardPath,
-		Bucket:          conf.S3.PrivateBucket,
+		Storage:         store,
 		Folder:          folder,
 		Filename:        reportData.ScanID + "-full-report",
 		Extension:       ".html",
 		Proxy:           conf.Proxy.Endpoint,
-		UploadToS3:      conf.S3.Upload,
-		AWSConfig:       awsConfig,
+		Uploads:         conf.Storage.Uploads,
 
 		Risk:                    report.RankSeverity(aggregatedScore),
 		ScanID:                  reportData.ScanID,
@@ -103,6 +101,14 @@ func GenerateFullReport(conf config.Config, awsConfig *aws.Config, folder string
 		Vulnerabilities:         vulnCount,
 		VulnerabilitiesPerAsset: assetVulnsSlice,
 		Groups:                  generateGroups(reportData),
+		MissingChecks:           reportData.MissingChecks,
+		Coverage:                reportData.Coverage,
+		Diff:                    reportData.Diff,
+		Suppressed:              reportData.Suppressed,
+		ExpiredSuppressions:     reportData.ExpiredSuppressions,
+		Overrides:               reportData.Overrides,
+		AssetBreakdowns:         reportData.AssetBreakdowns(),
+		AssetTypes:              reportData.AssetTypeSummaries(),
 		DocumentationLink:       conf.General.DocumentationLink,
 		RoadmapLink:             conf.General.RoadmapLink,
 		Jira:                    conf.General.Jira,
@@ -112,15 +118,17 @@ func GenerateFullReport(conf config.Config, awsConfig *aws.Config, folder string
 		GAID: conf.Analytics.GAID,
 	}
 
-	return fullReport.Generate()
+	return fullReport.Generate(ctx)
 }
 
 func convertToGroups(reportData *vulcan.ReportData, assetVulnsSlice []AssetVulns) []AssetVulns {
 	var result []AssetVulns
 	for _, entry := range assetVulnsSlice {
 		av := AssetVulns{
-			Asset: entry.Asset,
-			Count: entry.Count,
+			Asset:    entry.Asset,
+			Type:     entry.Type,
+			Metadata: entry.Metadata,
+			Count:    entry.Count,
 		}
 
 		groups := reportData.GroupsPerAsset[entry.Asset]
@@ -130,6 +138,7 @@ func convertToGroups(reportData *vulcan.ReportData, assetVulnsSlice []AssetVulns
 			}
 
 			var vulns []report.Vulnerability
+			var findings []vulcan.Vulnerability
 			// groupedRecommendations contain all the recommendations for a
 			// vulnerability with multiple "sub-vunerabilities".
 			// This only applies to vulcan-tls check, which for example:
@@ -146,6 +155,7 @@ func convertToGroups(reportData *vulcan.ReportData, assetVulnsSlice []AssetVulns
 					}
 				}
 				vulns = append(vulns, vuln.Vulnerability)
+				findings = append(findings, reportData.GroupedFindings(entry.Asset, vuln)...)
 			}
 
 			v := vulcan.Vulnerability{
@@ -157,6 +167,8 @@ func convertToGroups(reportData *vulcan.ReportData, assetVulnsSlice []AssetVulns
 				},
 			}
 			v.Vulnerability.Vulnerabilities = vulns
+			reportData.SetLifecycle(&v, findings...)
+			reportData.SetOverride(&v, findings...)
 
 			av.Vulns = append(av.Vulns, v)
 		}
@@ -181,6 +193,14 @@ func generateGroups(reportData *vulcan.ReportData) []Group {
 				CheckType:       vuln.Checktype,
 				Vulnerability:   vuln.Vulnerability,
 			}
+			// The lifecycle and the override are the ones of the findings
+			// of every target, not of the one shown.
+			var findings []vulcan.Vulnerability
+			for _, target := range vuln.AffectedTargets {
+				findings = append(findings, reportData.GroupedFindings(target, vuln)...)
+			}
+			reportData.SetLifecycle(&v, findings...)
+			reportData.SetOverride(&v, findings...)
 			vulns = append(vulns, v)
 		}
 
@@ -0,0 +1,115 @@
+package report
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/adevinta/security-overview/config"
+	"github.com/adevinta/security-overview/vulcan"
+	vulcanreport "github.com/adevinta/vulcan-report"
+)
+
+const testReport = `{"check_id":"%s","checktype_name":"vulcan-tls","status":"FINISHED","target":"%s","start_time":"2022-10-12 10:00:00","vulnerabilities":[%s]}`
+
+const testVulnerability = `{"summary":"Weak TLS","score":%v,"fingerprint":"%s","affected_resource":"%s"}`
+
+// newTestReportData returns the report data of a scan where the same
+// vulnerability is found in two assets, and twice in b.example.com, with
+// different scores. The grouping database only keeps the finding with the
+// highest score, the one of a.example.com. The first finding of b.example.com
+// is overridden by the policy and the second one was found before.
+func newTestReportData(t *testing.T) *vulcan.ReportData {
+	dir := t.TempDir()
+	files := map[string]string{
+		"scan/scan.json":      `{"id":"scan","start_time":"2022-10-12T10:00:00Z"}`,
+		"scan/reports/a.json": fmt.Sprintf(testReport, "a", "a.example.com", fmt.Sprintf(testVulnerability, 6.9, "fa", "443")),
+		"scan/reports/b.json": fmt.Sprintf(testReport, "b", "b.example.com", fmt.Sprintf(testVulnerability, 6.0, "fb", "443")+","+fmt.Sprintf(testVulnerability, 5.0, "fc", "8443")),
+		"policy.toml":         "[[override]]\nfingerprint = \"fb\"\nadjust = 0.5\nreason = \"Exposed\"\nowner = \"jane@example.com\"\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	policy, err := vulcan.ReadPolicy(filepath.Join(dir, "policy.toml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conf := config.Config{}
+	conf.Results.Workers = 1
+	source := &vulcan.DirSource{Dir: dir}
+	rd, err := vulcan.GetReportDataFromSource(context.Background(), conf, source, "scan", vulcan.Options{Policy: policy})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := vulcan.Vulnerability{Asset: "b.example.com", CheckType: "vulcan-tls", Vulnerability: vulcanreport.Vulnerability{Fingerprint: "fc"}}
+	rd.SetFindingDates(map[string]vulcan.FindingDates{vulcan.FindingKey(b): {FirstSeen: "2022-10-01"}}, config.SLAConfig{})
+	return rd
+}
+
+func TestGenerateGroupsFindings(t *testing.T) {
+	rd := newTestReportData(t)
+
+	var got []vulcan.Vulnerability
+	for _, g := range generateGroups(rd) {
+		got = append(got, g.Vulns...)
+	}
+	if len(got) != 1 {
+		t.Fatalf("unexpected vulnerabilities: %+v", got)
+	}
+	// The vulnerability shows the finding of a.example.com, but its lifecycle
+	// and its override are the ones of the findings of b.example.com.
+	v := got[0]
+	if v.Vulnerability.Fingerprint != "fa" {
+		t.Errorf("unexpected fingerprint: got %q, want %q", v.Vulnerability.Fingerprint, "fa")
+	}
+	if v.FirstSeen != "2022-10-01" {
+		t.Errorf("unexpected first seen: got %q, want %q", v.FirstSeen, "2022-10-01")
+	}
+	if v.Override == nil || v.Override.Rule.Reason != "Exposed" {
+		t.Errorf("unexpected override: %+v", v.Override)
+	}
+}
+
+func TestConvertToGroupsFindings(t *testing.T) {
+	rd := newTestReportData(t)
+
+	assets := convertToGroups(rd, []AssetVulns{{Asset: "a.example.com"}, {Asset: "b.example.com"}})
+	tests := []struct {
+		asset           string
+		wantFingerprint string
+		wantFirstSeen   string
+		wantOverride    bool
+	}{
+		{asset: "a.example.com", wantFingerprint: "fa"},
+		{asset: "b.example.com", wantFingerprint: "fb", wantFirstSeen: "2022-10-01", wantOverride: true},
+	}
+	for i, tt := range tests {
+		t.Run(tt.asset, func(t *testing.T) {
+			av := assets[i]
+			if av.Asset != tt.asset || len(av.Vulns) != 1 {
+				t.Fatalf("unexpected vulnerabilities: %+v", av)
+			}
+			// Every asset has the lifecycle and the override of its own
+			// findings, not only of the one shown.
+			v := av.Vulns[0]
+			if got := v.Vulnerability.Vulnerabilities[0].Fingerprint; got != tt.wantFingerprint {
+				t.Errorf("unexpected fingerprint: got %q, want %q", got, tt.wantFingerprint)
+			}
+			if v.FirstSeen != tt.wantFirstSeen {
+				t.Errorf("unexpected first seen: got %q, want %q", v.FirstSeen, tt.wantFirstSeen)
+			}
+			if (v.Override != nil) != tt.wantOverride {
+				t.Errorf("unexpected override: %+v", v.Override)
+			}
+		})
+	}
+}
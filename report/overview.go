@@ -1,15 +1,15 @@
 package report
 
 import (
-	"os"
-	"path/filepath"
+	"bytes"
+	"context"
 	"text/template"
 	"time"
 
-	"github.com/aws/aws-sdk-go/aws"
 	"github.com/danfaizer/go-chart"
 
 	"github.com/adevinta/security-overview/resources"
+	"github.com/adevinta/security-overview/storage"
 	"github.com/adevinta/security-overview/vulcan"
 )
 
@@ -19,9 +19,13 @@ const (
 
 // Overview ...
 type Overview struct {
-	LocalTempDir string
+	// Storage is where the charts are published, Uploads of them in
+	// parallel, and Output where the HTML is written.
+	Storage storage.Storage
+	Uploads int
+	Output  storage.Storage
+	charts  *publisher
 
-	Bucket         string
 	Folder         string
 	Filename       string
 	Extension      string
@@ -31,8 +35,6 @@ type Overview struct {
 	ContactEmail   string
 	ContactChannel string
 	Proxy          string
-	UploadToS3     bool
-	AWSConfig      *aws.Config
 
 	ScanID   string
 	TeamID   string
@@ -43,8 +45,11 @@ type Overview struct {
 	ImpactLevel          string
 	ImpactLevelStyle     string
 	VulnerabilitiesCount string
+	MissingChecks        int
+	Diff                 *vulcan.Diff
 
 	TopVulnerabilities     []vulcan.VulnerabilityCount
+	AssetBreakdowns        []vulcan.AssetBreakdown
 	VulnerabilityPerImpact Chart
 	VulnerabilityPerAsset  Chart
 	VulnerableAssetsChart  HistoricalChart
@@ -62,26 +67,46 @@ type HistoricalChart struct {
 	Dates    []time.Time
 }
 
-func (o *Overview) Generate() (string, error) {
-	err := o.HandleVulnerabilityPerImpact()
+// drawable returns true if the chart spans more than one day.
+func (c HistoricalChart) drawable() bool {
+	return len(c.Dates) > 1 && c.Dates[len(c.Dates)-1].After(c.Dates[0])
+}
+
+// Generate publishes the charts and the HTML of the overview. The charts are
+// uploaded while the next ones are drawn, and the HTML is only published once
+// they are.
+func (o *Overview) Generate(ctx context.Context) (string, error) {
+	o.charts = newPublisher(ctx, o.Storage, o.Uploads)
+	defer func() {
+		// The charts already queued are not left uploading on errors.
+		o.charts.Wait()
+		o.charts = nil
+	}()
+
+	err := o.HandleVulnerabilityPerImpact(ctx)
 	if err != nil {
 		return "", err
 	}
 
-	err = o.HandleVulnerabilityPerAsset()
+	err = o.HandleVulnerabilityPerAsset(ctx)
 	if err != nil {
 		return "", err
 	}
 
-	//err = o.HandleVulnerableAssetsChart()
-	//if err != nil {
-	//	return "", err
-	//}
+	// The historical charts are only drawn when there is history.
+	if o.VulnerableAssetsChart.drawable() {
+		err = o.HandleVulnerableAssetsChart(ctx)
+		if err != nil {
+			return "", err
+		}
+	}
 
-	//err = o.HandleImpactLevelChart()
-	//if err != nil {
-	//	return "", err
-	//}
+	if o.ImpactLevelChart.drawable() {
+		err = o.HandleImpactLevelChart(ctx)
+		if err != nil {
+			return "", err
+		}
+	}
 
 	reportTemplate := template.New("report").Funcs(template.FuncMap{"now": time.Now})
 
@@ -90,21 +115,14 @@ func (o *Overview) Generate() (string, error) {
 		return "", err
 	}
 
-	overviewHTMLURL, overviewHTMLPath, err := GenerateLocalFilePathAndRemoteURL("", o.Bucket, o.Folder, filepath.Join(o.LocalTempDir, o.ScanID), o.Filename, o.Extension)
-	if err != nil {
-		return "", err
-	}
-
-	file, err := os.Create(overviewHTMLPath)
+	var buf bytes.Buffer
+	err = reportHTML.ExecuteTemplate(&buf, templateFile, o)
 	if err != nil {
 		return "", err
 	}
-	defer file.Close()
-
-	err = reportHTML.ExecuteTemplate(file, templateFile, o)
-	if err != nil {
+	if err := o.charts.Wait(); err != nil {
 		return "", err
 	}
 
-	return overviewHTMLURL, nil
+	return PutFile(ctx, o.Output, "", o.Filename, o.Extension, buf.Bytes())
 }
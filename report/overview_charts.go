@@ -1,8 +1,8 @@
 package report
 
 import (
+	"context"
 	"fmt"
-	"path/filepath"
 
 	"github.com/danfaizer/go-chart"
 	"github.com/danfaizer/go-chart/drawing"
@@ -10,7 +10,7 @@ import (
 	"github.com/adevinta/security-overview/utils"
 )
 
-func (o *Overview) HandleVulnerabilityPerImpact() error {
+func (o *Overview) HandleVulnerabilityPerImpact(ctx context.Context) error {
 	chart.DefaultAlternateColors = []drawing.Color{
 		drawing.ColorFromHex("9239ff"), // Critical
 		drawing.ColorFromHex("ff3860"), // High
@@ -44,7 +44,7 @@ func (o *Overview) HandleVulnerabilityPerImpact() error {
 		return err
 	}
 
-	currentImageURL, err := utils.GenerateLocalFile(currentImage, o.Proxy, o.Bucket, o.Folder, filepath.Join(o.LocalTempDir, o.ScanID, o.Bucket, o.Folder), "", utils.ExtensionPNG)
+	currentImageURL, err := o.putChart(ctx, currentImage)
 	if err != nil {
 		return err
 	}
@@ -55,7 +55,7 @@ func (o *Overview) HandleVulnerabilityPerImpact() error {
 	return nil
 }
 
-func (o *Overview) HandleVulnerabilityPerAsset() error {
+func (o *Overview) HandleVulnerabilityPerAsset(ctx context.Context) error {
 	chart.DefaultAlternateColors = BulmaPalette
 	values := o.VulnerabilityPerAsset.Values
 	if len(values) > 6 {
@@ -104,7 +104,7 @@ func (o *Overview) HandleVulnerabilityPerAsset() error {
 	}
 
 	// Upload the output image to S3 (or save it locally)
-	currentImageURL, err := utils.GenerateLocalFile(currentImage, o.Proxy, o.Bucket, o.Folder, filepath.Join(o.LocalTempDir, o.ScanID, o.Bucket, o.Folder), "", utils.ExtensionPNG)
+	currentImageURL, err := o.putChart(ctx, currentImage)
 	if err != nil {
 		return err
 	}
@@ -115,7 +115,7 @@ func (o *Overview) HandleVulnerabilityPerAsset() error {
 	return nil
 }
 
-func (o *Overview) HandleVulnerableAssetsChart() error {
+func (o *Overview) HandleVulnerableAssetsChart(ctx context.Context) error {
 	chart.DefaultAlternateColors = BulmaPalette
 
 	max := 0.0
@@ -179,7 +179,7 @@ func (o *Overview) HandleVulnerableAssetsChart() error {
 	}
 
 	// Upload the output image to S3 (or save it locally)
-	currentImageURL, err := utils.GenerateLocalFile(currentImage, o.Proxy, o.Bucket, o.Folder, filepath.Join(o.LocalTempDir, o.ScanID, o.Folder), "", utils.ExtensionPNG)
+	currentImageURL, err := o.putChart(ctx, currentImage)
 	if err != nil {
 		return err
 	}
@@ -190,7 +190,7 @@ func (o *Overview) HandleVulnerableAssetsChart() error {
 	return nil
 }
 
-func (o *Overview) HandleImpactLevelChart() error {
+func (o *Overview) HandleImpactLevelChart(ctx context.Context) error {
 	chart.DefaultAlternateColors = BulmaPalette
 
 	historicalChart := chart.Chart{
@@ -220,7 +220,7 @@ func (o *Overview) HandleImpactLevelChart() error {
 			},
 			Range: &chart.ContinuousRange{
 				Min: 0.0,
-				Max: 3.0,
+				Max: 4.0,
 			},
 			Ticks: []chart.Tick{
 				chart.Tick{Label: "None", Value: 0.0},
@@ -250,7 +250,7 @@ func (o *Overview) HandleImpactLevelChart() error {
 	}
 
 	// Upload the output image to S3 (or save it locally)
-	currentImageURL, err := utils.GenerateLocalFile(currentImage, o.Proxy, o.Bucket, o.Folder, filepath.Join(o.LocalTempDir, o.ScanID, o.Folder), "", utils.ExtensionPNG)
+	currentImageURL, err := o.putChart(ctx, currentImage)
 	if err != nil {
 		return err
 	}
@@ -260,3 +260,12 @@ func (o *Overview) HandleImpactLevelChart() error {
 
 	return nil
 }
+
+// putChart publishes the image of a chart and returns its URL. While the
+// overview is generated the images are uploaded in the background.
+func (o *Overview) putChart(ctx context.Context, image []byte) (string, error) {
+	if o.charts == nil {
+		return PutFile(ctx, o.Storage, o.Folder, "", utils.ExtensionPNG, image)
+	}
+	return o.charts.PutFile(o.Folder, "", utils.ExtensionPNG, image), nil
+}
@@ -1,21 +1,27 @@
 package report
 
 import (
+	"context"
 	"fmt"
 	"net/url"
 	"strconv"
 	"time"
 
-	"github.com/aws/aws-sdk-go/aws"
 	"github.com/danfaizer/go-chart"
 
 	"github.com/adevinta/security-overview/config"
+	"github.com/adevinta/security-overview/history"
+	"github.com/adevinta/security-overview/storage"
 	"github.com/adevinta/security-overview/vulcan"
 )
 
 // GenerateOverview generates content of the overview report suitable to be send as email.
-// Returns the url or the file path, depending on configuration, where the report generated is stored.
-func GenerateOverview(conf config.Config, awsConfig *aws.Config, folder string, reportData *vulcan.ReportData, teamName, teamID, scanID string) (string, error) {
+// The charts are published in the given storage and the HTML is written to the output one.
+// Returns the url or the file path, depending on the output, where the report generated is stored.
+// The historical charts are drawn from the given history of the team, that is
+// expected to include the scan. If the link to the full report is not empty the
+// overview uses it instead of to the report view endpoint.
+func GenerateOverview(ctx context.Context, conf config.Config, store, output storage.Storage, folder string, reportData *vulcan.ReportData, hist []history.Entry, teamName, teamID, scanID, linkFullReport string) (string, error) {
 	// assemble the array of vulnerabilities per checktype
 	vulnerabilityPerImpact := []chart.Value{}
 	vulnerabilitiesCount := 0
@@ -70,24 +76,35 @@ func GenerateOverview(conf config.Config, awsConfig *aws.Config, folder string,
 		actionRequiredStyle = "green"
 	}
 
-	// obtain the scan date
-	endDate, err := time.Parse("2006-01-02", reportData.Date)
-	if err != nil {
-		return "", err
+	// assemble the history of the vulnerable assets and the impact level
+	vulnerableAssetsChart := HistoricalChart{}
+	impactLevelChart := HistoricalChart{}
+	for _, e := range hist {
+		date, err := time.Parse("2006-01-02", e.Date)
+		if err != nil {
+			return "", err
+		}
+		vulnerableAssetsChart.Dates = append(vulnerableAssetsChart.Dates, date)
+		vulnerableAssetsChart.Values = append(vulnerableAssetsChart.Values, float64(e.VulnerableAssets))
+		impactLevelChart.Dates = append(impactLevelChart.Dates, date)
+		impactLevelChart.Values = append(impactLevelChart.Values, float64(e.Risk))
 	}
-	// obtain the scan date - 1 month
-	startDate := endDate.AddDate(0, -1, 0)
 
 	// Generate the ful report link poiting to the vulcan-api report view endpoint
 	// e.g. https://vulcan.example.com/api/v1/report?team_id=%s&scan_id=%s
 	fullReportLink := fmt.Sprintf(conf.Endpoints.ViewReport, url.QueryEscape(teamID), url.QueryEscape(scanID))
+	if linkFullReport != "" {
+		// The full report is linked directly, for instance with a
+		// pre-signed URL.
+		fullReportLink = linkFullReport
+	}
 	fullReportURL, err := url.Parse(fullReportLink)
 	if err != nil {
 		return "", err
 	}
 	RedirectURLURL := fullReportURL
 	// If RedirectURL is not empty, we want to wrap report access through VPN.
-	if conf.Endpoints.RedirectURL != "" {
+	if conf.Endpoints.RedirectURL != "" && linkFullReport == "" {
 		// Wrap the link over the redirect endpoint that ensures the user is connected to VPN.
 		// Example of RedirectURL https://vulcan-insights-redirect.example.com/index.html?reportUrl=vulcan-dev.example.com/api/v1/report?team_id=team-id&scan_id=scan-id
 		RedirectURLURL, err = url.Parse(conf.Endpoints.RedirectURL)
@@ -99,19 +116,18 @@ func GenerateOverview(conf config.Config, awsConfig *aws.Config, folder string,
 		RedirectURLURL.RawQuery = RedirectURLURL.RawQuery + url.QueryEscape(fullReportURL.String())
 	}
 	overview := Overview{
-		LocalTempDir:         conf.General.LocalTempDir,
+		Storage:              store,
+		Uploads:              conf.Storage.Uploads,
+		Output:               output,
 		CompanyName:          conf.General.CompanyName,
 		SupportEmail:         conf.General.SupportEmail,
 		ContactEmail:         conf.General.ContactEmail,
 		ContactChannel:       conf.General.ContactChannel,
-		Bucket:               conf.S3.PublicBucket,
 		Folder:               folder,
 		Filename:             reportData.ScanID + "-overview",
 		Extension:            ".html",
 		LinkFullReport:       RedirectURLURL.String(),
 		Proxy:                conf.Proxy.Endpoint,
-		UploadToS3:           conf.S3.Upload,
-		AWSConfig:            awsConfig,
 		ScanID:               reportData.ScanID,
 		TeamID:               teamID,
 		TeamName:             teamName,
@@ -121,26 +137,18 @@ func GenerateOverview(conf config.Config, awsConfig *aws.Config, folder string,
 		ImpactLevelStyle:     riskStyle,
 		VulnerabilitiesCount: strconv.Itoa(vulnerabilitiesCount),
 		TopVulnerabilities:   reportData.TopVulnerabilities,
+		AssetBreakdowns:      append([]vulcan.AssetBreakdown{reportData.AssetTypeBreakdown()}, reportData.AssetBreakdowns()...),
+		MissingChecks:        len(reportData.MissingChecks),
+		Diff:                 reportData.Diff,
 		VulnerabilityPerImpact: Chart{
 			Values: vulnerabilityPerImpact,
 		},
 		VulnerabilityPerAsset: Chart{
 			Values: vulnerabilityPerAsset,
 		},
-		//Since this is the first report, we are going to show just a flat line
-		//over the last 30 days
-		VulnerableAssetsChart: HistoricalChart{
-			Dates:  []time.Time{startDate, endDate},
-			Values: []float64{float64(reportData.NumberOfVulnerableAssets), float64(reportData.NumberOfVulnerableAssets)},
-		},
-
-		//Since this is the first report, we are going to show just a flat line
-		//over the last 30 days
-		ImpactLevelChart: HistoricalChart{
-			Dates:  []time.Time{startDate, endDate},
-			Values: []float64{float64(reportData.Risk), float64(reportData.Risk)},
-		},
+		VulnerableAssetsChart: vulnerableAssetsChart,
+		ImpactLevelChart:      impactLevelChart,
 	}
 
-	return overview.Generate()
+	return overview.Generate(ctx)
 }
@@ -0,0 +1,95 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"html/template"
+	"log"
+	"path/filepath"
+
+	"github.com/adevinta/security-overview/config"
+	"github.com/adevinta/security-overview/resources"
+	"github.com/adevinta/security-overview/storage"
+	"github.com/adevinta/security-overview/vulcan"
+)
+
+const (
+	templateFilePortfolio = "portfolio.html"
+)
+
+// PortfolioReport is the report merging the scans of several teams.
+type PortfolioReport struct {
+	Storage   storage.Storage `json:"-"`
+	Folder    string          `json:"-"`
+	Filename  string          `json:"-"`
+	Extension string          `json:"-"`
+	Proxy     string          `json:"-"`
+	Uploads   int             `json:"-"`
+
+	Name string `json:"name"`
+	Date string `json:"date"`
+	*vulcan.Portfolio
+
+	GAID string `json:"-"`
+}
+
+// GeneratePortfolioReport generates the html report merging the scans of
+// several teams, suitable to be published as a static web page. Returns the
+// url or the file path, depending on the storage, where the report generated
+// is stored.
+func GeneratePortfolioReport(ctx context.Context, conf config.Config, store storage.Storage, folder, name, date string, portfolio *vulcan.Portfolio) (string, error) {
+	pr := PortfolioReport{
+		Storage:   store,
+		Folder:    folder,
+		Filename:  "portfolio",
+		Extension: ".html",
+		Proxy:     conf.Proxy.Endpoint,
+		Uploads:   conf.Storage.Uploads,
+		Name:      name,
+		Date:      date,
+		Portfolio: portfolio,
+		GAID:      conf.Analytics.GAID,
+	}
+	return pr.Generate(ctx)
+}
+
+// Generate publishes the JSON and the HTML files of the report. The HTML is
+// only published once the JSON and the resources it links are.
+func (pr *PortfolioReport) Generate(ctx context.Context) (string, error) {
+	files := newPublisher(ctx, pr.Storage, pr.Uploads)
+	generateFuncs := templateFuncs(func(path string) string {
+		ext := filepath.Ext(path)
+		body, errUploadFile := resources.Files.ReadFile(path)
+		if errUploadFile != nil {
+			log.Println(errUploadFile)
+			return ""
+		}
+		return files.PutFile(pr.Folder, "", ext, body)
+	})
+
+	content, err := json.Marshal(pr)
+	if err != nil {
+		return "", err
+	}
+	files.PutFile(pr.Folder, pr.Filename, ".json", content)
+
+	reportTemplate := template.New("portfolio").Funcs(generateFuncs)
+	reportHTML, err := reportTemplate.ParseFS(resources.Files, templateFilePortfolio)
+	if err != nil {
+		files.Wait()
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	err = reportHTML.ExecuteTemplate(&buf, templateFilePortfolio, pr)
+	if err != nil {
+		files.Wait()
+		return "", err
+	}
+	if err := files.Wait(); err != nil {
+		return "", err
+	}
+
+	return PutFile(ctx, pr.Storage, pr.Folder, pr.Filename, pr.Extension, buf.Bytes())
+}
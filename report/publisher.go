@@ -0,0 +1,103 @@
+package report
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/adevinta/security-overview/storage"
+)
+
+// publisher publishes the files of a report in a storage with a bounded
+// number of concurrent uploads. The URL of a file is returned as soon as it
+// is queued, so the documents linking it can be generated while it is
+// uploaded.
+type publisher struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	store  storage.Storage
+	sem    chan struct{}
+	wg     sync.WaitGroup
+
+	mu   sync.Mutex
+	keys map[string]bool
+	err  error
+}
+
+// newPublisher returns a publisher uploading up to the given number of files
+// at the same time, at least one. The uploads are canceled with the context
+// or as soon as one of them fails.
+func newPublisher(ctx context.Context, store storage.Storage, uploads int) *publisher {
+	if uploads < 1 {
+		uploads = 1
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	return &publisher{
+		ctx:    ctx,
+		cancel: cancel,
+		store:  store,
+		sem:    make(chan struct{}, uploads),
+		keys:   make(map[string]bool),
+	}
+}
+
+// PutFile queues the upload of the content to the given folder of the storage
+// and returns the URL where it is published, as PutFile does. The files
+// already queued are not uploaded again. It blocks while all the uploads are
+// in progress.
+func (p *publisher) PutFile(folder, filename, extension string, content []byte) string {
+	key := fileKey(folder, filename, extension, content)
+	url := p.store.URL(key)
+
+	p.mu.Lock()
+	queued := p.keys[key]
+	p.keys[key] = true
+	p.mu.Unlock()
+	if queued {
+		return url
+	}
+
+	select {
+	case p.sem <- struct{}{}:
+	case <-p.ctx.Done():
+		p.fail(p.ctx.Err())
+		return url
+	}
+	// The uploads queued after a failure are not started.
+	if p.ctx.Err() != nil {
+		<-p.sem
+		p.fail(p.ctx.Err())
+		return url
+	}
+	p.wg.Add(1)
+	go func() {
+		defer func() {
+			<-p.sem
+			p.wg.Done()
+		}()
+		if err := p.store.Put(p.ctx, key, content); err != nil {
+			p.fail(fmt.Errorf("error publishing %s: %w", key, err))
+		}
+	}()
+	return url
+}
+
+// fail records the first error and cancels the pending uploads.
+func (p *publisher) fail(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err == nil {
+		p.err = err
+		p.cancel()
+	}
+}
+
+// Wait blocks until all the queued files are uploaded and returns the first
+// error, if any.
+func (p *publisher) Wait() error {
+	p.wg.Wait()
+	p.cancel()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.err
+}
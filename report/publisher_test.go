@@ -0,0 +1,88 @@
+package report
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/adevinta/security-overview/storage"
+)
+
+// slowStorage is a storage whose uploads take some time, recording how many
+// of them are in progress at the same time. The uploads of the failing key
+// return an error.
+type slowStorage struct {
+	storage.MemStorage
+	failing string
+
+	mu      sync.Mutex
+	puts    int
+	running int
+	max     int
+}
+
+func (s *slowStorage) Put(ctx context.Context, key string, content []byte) error {
+	s.mu.Lock()
+	s.puts++
+	s.running++
+	if s.running > s.max {
+		s.max = s.running
+	}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.running--
+		s.mu.Unlock()
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	if key == s.failing {
+		return errors.New("upload failed")
+	}
+	return s.MemStorage.Put(ctx, key, content)
+}
+
+func TestPublisher(t *testing.T) {
+	tests := []struct {
+		name     string
+		uploads  int
+		files    int
+		failing  string
+		wantMax  int
+		wantPuts int
+		wantErr  bool
+	}{
+		{name: "bounded", uploads: 3, files: 10, wantMax: 3, wantPuts: 10},
+		{name: "serial", uploads: 0, files: 4, wantMax: 1, wantPuts: 4},
+		{name: "failed upload", uploads: 1, files: 4, failing: "reports/1.png", wantMax: 1, wantPuts: 2, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &slowStorage{MemStorage: storage.MemStorage{BaseURL: "https://example.com"}, failing: tt.failing}
+			p := newPublisher(context.Background(), s, tt.uploads)
+			for i := 0; i < tt.files; i++ {
+				name := fmt.Sprint(i)
+				url := p.PutFile("reports", name, ".png", []byte(name))
+				if want := "https://example.com/reports/" + name + ".png"; url != want {
+					t.Errorf("unexpected URL: got %q, want %q", url, want)
+				}
+				// The files already queued are not uploaded again.
+				p.PutFile("reports", name, ".png", []byte(name))
+			}
+			err := p.Wait()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if s.max != tt.wantMax {
+				t.Errorf("unexpected concurrent uploads: got %d, want %d", s.max, tt.wantMax)
+			}
+			// The pending uploads are canceled after the first error.
+			if s.puts != tt.wantPuts {
+				t.Errorf("unexpected uploads: got %d, want %d", s.puts, tt.wantPuts)
+			}
+		})
+	}
+}
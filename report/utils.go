@@ -3,14 +3,16 @@ package report
 import (
 	"bufio"
 	"bytes"
+	"context"
+	"crypto/sha256"
 	"errors"
 	"fmt"
-	"path/filepath"
-	"strings"
+	"path"
 
 	"github.com/danfaizer/go-chart"
 	"github.com/danfaizer/go-chart/drawing"
-	uuid "github.com/satori/go.uuid"
+
+	"github.com/adevinta/security-overview/storage"
 )
 
 // Material design color palette, according to:
@@ -122,27 +124,23 @@ func RiskToActionString(risk int) string {
 	}
 }
 
-func GenerateLocalFilePathAndRemoteURL(proxy, bucket, folder, localTempDir, filename, extension string) (string, string, error) {
-	if filename == "" {
-		u, err := uuid.NewV4()
-		if err != nil {
-			return "", "", err
-		}
-
-		filename = u.String()
-	}
-	path := filepath.Join(localTempDir, filename+extension)
-
-	if proxy == "" {
-		return filepath.Join(localTempDir, filename+extension), path, nil
-	}
-
-	if bucket == "" {
-		return filename + extension, path, nil
+// PutFile stores the content in the given folder of the storage and returns
+// the URL where it is published. If the filename is empty the hash of the
+// content is used, so the files that did not change keep their URLs and are
+// not published again.
+func PutFile(ctx context.Context, store storage.Storage, folder, filename, extension string, content []byte) (string, error) {
+	key := fileKey(folder, filename, extension, content)
+	if err := store.Put(ctx, key, content); err != nil {
+		return "", err
 	}
+	return store.URL(key), nil
+}
 
-	if strings.Contains(bucket, "public") {
-		return fmt.Sprintf("https://%s.s3.amazonaws.com/%s", bucket, filepath.Join(folder, filename+extension)), path, nil
+// fileKey returns the key of a file in the storage. If the filename is empty
+// the hash of the content is used.
+func fileKey(folder, filename, extension string, content []byte) string {
+	if filename == "" {
+		filename = fmt.Sprintf("%x", sha256.Sum256(content))
 	}
-	return fmt.Sprintf("%s/%s", proxy, filepath.Join(folder, filename+extension)), path, nil
+	return path.Join(folder, filename+extension)
 }
@@ -0,0 +1,240 @@
+package insights
+
+import (
+	"context"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/service/s3"
+
+	"github.com/adevinta/security-overview/config"
+	"github.com/adevinta/security-overview/vulcan"
+	"github.com/adevinta/security-overview/vulcan/client"
+)
+
+// hangingVulcan is a Vulcan API serving a scan of three checks whose reports
+// never arrive. The requests of the reports call onReport, if set, and hang
+// until they are canceled.
+type hangingVulcan struct {
+	onReport func()
+}
+
+func (h *hangingVulcan) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/v1/scans/scan":
+		fmt.Fprint(w, `{"id":"scan","start_time":"2022-10-12T10:00:00Z"}`)
+	case r.URL.Path == "/v1/scans/scan/checks":
+		var checks []string
+		for i := 1; i <= 3; i++ {
+			checks = append(checks, fmt.Sprintf(`{"id":"c%d","target":"example.com","status":"FINISHED","report":"/reports/c%d.json","checktype_name":"vulcan-tls"}`, i, i))
+		}
+		fmt.Fprintf(w, `{"checks":[%s],"pagination":{"page":1,"size":10,"total":3,"more":false}}`, strings.Join(checks, ","))
+	case strings.HasPrefix(r.URL.Path, "/reports/"):
+		if h.onReport != nil {
+			h.onReport()
+		}
+		<-r.Context().Done()
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// writeTestConfig writes a config collecting the scans from the given
+// endpoint, one report at a time without retries, and keeping the reports in
+// memory.
+func writeTestConfig(t *testing.T, endpoint string, requestTimeout, timeout time.Duration) string {
+	dir := t.TempDir()
+	content := fmt.Sprintf(`
+[s3]
+private_bucket = "private"
+public_bucket = "public"
+
+[storage]
+type = "memory"
+
+[endpoints]
+view_report = "https://vulcan.example.com/api/v1/report?team_id=%%s&scan_id=%%s"
+
+[general]
+local_temp_dir = %q
+timeout = "%s"
+
+[persistence]
+endpoint = %q
+
+[results]
+endpoint = %q
+workers = 1
+retries = -1
+request_timeout = "%s"
+`, dir, timeout, endpoint, endpoint, requestTimeout)
+	path := filepath.Join(dir, "config.toml")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// chdirTemp changes the working directory, where the report data of the team
+// is written, to a temporary one until the test finishes.
+func chdirTemp(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+func TestDetailedReportGenerateDeadlines(t *testing.T) {
+	chdirTemp(t)
+
+	tests := []struct {
+		name           string
+		requestTimeout time.Duration
+		timeout        time.Duration
+		cancel         bool
+		wantErr        error
+		wantFailed     int
+	}{
+		{name: "request deadline", requestTimeout: 50 * time.Millisecond, wantFailed: 3},
+		{name: "overall deadline", requestTimeout: time.Minute, timeout: 100 * time.Millisecond, wantErr: context.DeadlineExceeded},
+		{name: "canceled", requestTimeout: time.Minute, cancel: true, wantErr: context.Canceled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			h := &hangingVulcan{}
+			if tt.cancel {
+				h.onReport = cancel
+			}
+			srv := httptest.NewServer(h)
+			defer srv.Close()
+
+			d, err := NewDetailedReport(ctx, writeTestConfig(t, srv.URL, tt.requestTimeout, tt.timeout), "team", "scan", "team")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var last vulcan.Progress
+			d.SetProgress(func(p vulcan.Progress) { last = p })
+
+			done := make(chan error, 1)
+			go func() { done <- d.Generate(ctx) }()
+			select {
+			case err = <-done:
+			case <-time.After(10 * time.Second):
+				t.Fatal("the generation hangs")
+			}
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("unexpected error: got %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil {
+				// The reports that timed out are missing.
+				if last.Failed != tt.wantFailed {
+					t.Errorf("unexpected failed checks: got %d, want %d", last.Failed, tt.wantFailed)
+				}
+				return
+			}
+			// The number of checks of the scan is known even though not
+			// all of them have been sent to the workers.
+			var partial *vulcan.PartialCollectionError
+			if !errors.As(err, &partial) {
+				t.Fatalf("unexpected error: got %v, want a *PartialCollectionError", err)
+			}
+			if partial.Collected != 0 || partial.Total != 3 {
+				t.Errorf("unexpected collected reports: got %d of %d, want 0 of 3", partial.Collected, partial.Total)
+			}
+		})
+	}
+}
+
+func TestNewDetailedReportCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := NewDetailedReport(ctx, "missing.toml", "team", "scan", "team"); !errors.Is(err, context.Canceled) {
+		t.Errorf("unexpected error: got %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestDetailedReportDeprecated(t *testing.T) {
+	chdirTemp(t)
+
+	srv := httptest.NewServer(&hangingVulcan{})
+	defer srv.Close()
+	d, err := NewDetailedReport(context.Background(), writeTestConfig(t, srv.URL, 50*time.Millisecond, 0), "team", "scan", "team")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The deprecated methods generate and publish the report as before.
+	if err := d.GenerateLocalFiles(); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.UploadFilesToS3(); err != nil {
+		t.Fatal(err)
+	}
+	if d.URL == "" {
+		t.Error("report not published")
+	}
+}
+
+func TestNewSessionHTTPClient(t *testing.T) {
+	// The SDK replaces the authorities of the transport with the ones of
+	// the CA bundle of its environment.
+	t.Setenv("AWS_CA_BUNDLE", "")
+
+	var gotUA string
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUA = r.Header.Get("User-Agent")
+	}))
+	defer srv.Close()
+
+	// The S3 endpoint is only trusted by the transport built from the config.
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(bundle, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	conf := config.Config{}
+	conf.HTTP.CABundle = bundle
+	conf.S3.Endpoint = srv.URL
+	conf.S3.PathStyle = true
+	transport, err := vulcan.NewHTTPTransport(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	awsConfig := newAWSConfig(&conf, transport)
+	if conf.S3.Region != "eu-west-1" {
+		t.Errorf("unexpected region: got %q, want %q", conf.S3.Region, "eu-west-1")
+	}
+	awsConfig.WithCredentials(credentials.NewStaticCredentials("id", "secret", ""))
+	sess, err := newSession(awsConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = s3.New(sess).PutObjectWithContext(context.Background(), &s3.PutObjectInput{
+		Bucket: aws.String("reports"),
+		Key:    aws.String("team/report.html"),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The User-Agent of the tool is sent along with the one of the SDK.
+	if !strings.Contains(gotUA, client.UserAgent()) || !strings.Contains(gotUA, aws.SDKName) {
+		t.Errorf("unexpected User-Agent: got %q, want %q and %q", gotUA, client.UserAgent(), aws.SDKName)
+	}
+}
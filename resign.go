@@ -0,0 +1,106 @@
+package insights
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"html"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/adevinta/security-overview/config"
+	"github.com/adevinta/security-overview/storage"
+	"github.com/adevinta/security-overview/vulcan"
+)
+
+// ResignReports signs again the URLs of the files of the reports of a team
+// published in the given date, YYYY-MM-DD, so they can be accessed for the
+// given time, or the one defined in the config if it is zero. The links
+// between the files are updated and the new URLs of the HTML reports are
+// returned.
+func ResignReports(ctx context.Context, configFile, teamName, date string, expiry time.Duration) ([]string, error) {
+	if _, err := time.Parse("2006-01-02", date); err != nil {
+		return nil, fmt.Errorf("invalid date %s: %w", date, err)
+	}
+
+	conf, err := config.ReadConfig(configFile)
+	if err != nil {
+		return nil, err
+	}
+	if expiry > 0 {
+		conf.S3.PresignExpiry = expiry
+	}
+	if conf.S3.PresignExpiry <= 0 || (conf.Storage.Type != "" && conf.Storage.Type != storage.TypeS3) {
+		return nil, errors.New("the URLs of the reports are only signed with the s3 storage and an expiry")
+	}
+
+	transport, err := vulcan.NewHTTPTransport(conf)
+	if err != nil {
+		return nil, err
+	}
+	awsConfig := newAWSConfig(&conf, transport)
+	private, _, err := newStorages(conf, awsConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	// The files of the reports are published in the folder
+	// hex(sha256(teamName))/YYYY-MM-DD.
+	folder := path.Join(fmt.Sprintf("%x", sha256.Sum256([]byte(teamName))), date)
+	keys, err := private.List(ctx, folder+"/")
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no reports of the team %s published in %s", teamName, date)
+	}
+
+	links := make([]signedLink, 0, len(keys))
+	for _, key := range keys {
+		links = append(links, newSignedLink(private.URL(key)))
+	}
+
+	var urls []string
+	for _, key := range keys {
+		if path.Ext(key) != ".html" {
+			continue
+		}
+		content, err := private.Get(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		for _, l := range links {
+			content = l.replace(content)
+		}
+		if err := private.Put(ctx, key, content); err != nil {
+			return nil, err
+		}
+		urls = append(urls, private.URL(key))
+	}
+	return urls, nil
+}
+
+// signedLink replaces the previously signed URLs of a file in the HTML
+// documents with a new one.
+type signedLink struct {
+	re  *regexp.Regexp
+	url []byte
+}
+
+func newSignedLink(url string) signedLink {
+	// The query of a pre-signed URL contains the signature, so any URL of the
+	// same object with a query is considered a previous signature. The
+	// ampersands of the query are escaped in the HTML.
+	base, _, _ := strings.Cut(url, "?")
+	return signedLink{
+		re:  regexp.MustCompile(regexp.QuoteMeta(base) + `\?[^"'\s<>]*`),
+		url: []byte(html.EscapeString(url)),
+	}
+}
+
+func (l signedLink) replace(content []byte) []byte {
+	return l.re.ReplaceAllLiteral(content, l.url)
+}
@@ -0,0 +1,30 @@
+package insights
+
+import "testing"
+
+func TestSignedLinkReplace(t *testing.T) {
+	const base = "https://reports.s3.eu-west-1.amazonaws.com/abc/2022-10-12/report.html"
+	l := newSignedLink(base + "?X-Amz-Date=new&X-Amz-Signature=new")
+	want := `href="` + base + `?X-Amz-Date=new&amp;X-Amz-Signature=new"`
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{name: "signed", content: `href="` + base + `?X-Amz-Date=old&X-Amz-Signature=old"`, want: want},
+		{name: "escaped", content: `href="` + base + `?X-Amz-Date=old&amp;X-Amz-Signature=old"`, want: want},
+		{name: "single quotes", content: `href='` + base + `?X-Amz-Signature=old'`, want: `href='` + base + `?X-Amz-Date=new&amp;X-Amz-Signature=new'`},
+		{name: "several links", content: base + "?a " + base + "?b", want: base + "?X-Amz-Date=new&amp;X-Amz-Signature=new " + base + "?X-Amz-Date=new&amp;X-Amz-Signature=new"},
+		{name: "not signed", content: `href="` + base + `"`, want: `href="` + base + `"`},
+		{name: "other file", content: `href="https://reports.s3.eu-west-1.amazonaws.com/abc/2022-10-12/data.json?X-Amz-Signature=old"`, want: `href="https://reports.s3.eu-west-1.amazonaws.com/abc/2022-10-12/data.json?X-Amz-Signature=old"`},
+		{name: "other date", content: `href="https://reports.s3.eu-west-1.amazonaws.com/abc/2022-10-05/report.html?X-Amz-Signature=old"`, want: `href="https://reports.s3.eu-west-1.amazonaws.com/abc/2022-10-05/report.html?X-Amz-Signature=old"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(l.replace([]byte(tt.content))); got != tt.want {
+				t.Errorf("unexpected content: got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -50,8 +50,10 @@ $("#filter-parents-query").keyup(function (e) {
         }
       }
     });
-  } else if ($("#tab-assets").hasClass("is-active")) {
-    assets = $(this).closest(".columns").find(".asset");
+  } else {
+    // The rest of sections contain a card per asset.
+    section = $(".tabs li.is-active").data("section");
+    assets = $("#" + section).children(".card");
     $.each(assets, function (index, asset) {
       asset = $(asset)
       if (asset.children(".card-header").text().toLowerCase().indexOf(query) < 0) {
@@ -165,29 +167,14 @@ $(".tabs li").click(function (event) {
     return
   }
   if (!tab.hasClass("is-active")) {
+    $(".tabs li").removeClass("is-active");
     tab.addClass("is-active");
-    if (tab.attr('id') == "tab-issues") {
-      $("#tab-assets").removeClass("is-active");
-      $("#tab-dashboard").removeClass("is-active");
-      $("#assets").css("display", "none");
-      $("#dashboard").css("display", "none");
-      $("#issues").css("display", "");
+    $(".report-section").css("display", "none");
+    $("#" + tab.data("section")).css("display", "");
+    if (tab.data("filter")) {
       $("#filter-parents").css("display", "");
-      $("#filter-parents-query").attr("placeholder", "Find an issue")
-    } else if (tab.attr('id') == "tab-assets") {
-      $("#tab-issues").removeClass("is-active");
-      $("#tab-dashboard").removeClass("is-active");
-      $("#issues").css("display", "none");
-      $("#dashboard").css("display", "none");
-      $("#assets").css("display", "");
-      $("#filter-parents").css("display", "");
-      $("#filter-parents-query").attr("placeholder", "Find an asset")
-    } else if (tab.attr('id') == "tab-dashboard") {
-      $("#tab-issues").removeClass("is-active");
-      $("#tab-assets").removeClass("is-active");
-      $("#issues").css("display", "none");
-      $("#assets").css("display", "none");
-      $("#dashboard").css("display", "");
+      $("#filter-parents-query").attr("placeholder", tab.data("filter"))
+    } else {
       $("#filter-parents").css("display", "none");
     }
     $("#filter-parents-query").val("");
@@ -0,0 +1,186 @@
+package insights
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/adevinta/security-overview/config"
+	"github.com/adevinta/security-overview/storage"
+	"github.com/adevinta/security-overview/vulcan"
+)
+
+// Retention defines the reports of a team that are kept: the last Keep ones
+// and the ones published in the last Days days. A zero value disables the
+// corresponding criterion.
+type Retention struct {
+	Keep int
+	Days int
+}
+
+// ExpiredFile is a file of a report out of the retention.
+type ExpiredFile struct {
+	Bucket string
+	Key    string
+}
+
+// CleanReports deletes from both buckets the files of the reports of a team
+// out of the given retention and returns them. The stale incomplete uploads of
+// the team are aborted. The reports are stored in
+// folders with the format hex(sha256(teamName))/YYYY-MM-DD, so a report is
+// identified by its date. In dry-run mode the files are only returned.
+func CleanReports(ctx context.Context, configFile, teamName string, retention Retention, dryRun bool) ([]ExpiredFile, error) {
+	if retention.Keep < 0 || retention.Days < 0 {
+		return nil, errors.New("the retention can not be negative")
+	}
+	if retention.Keep == 0 && retention.Days == 0 {
+		return nil, errors.New("no retention defined")
+	}
+
+	conf, err := config.ReadConfig(configFile)
+	if err != nil {
+		return nil, err
+	}
+	transport, err := vulcan.NewHTTPTransport(conf)
+	if err != nil {
+		return nil, err
+	}
+	awsConfig := newAWSConfig(&conf, transport)
+	private, public, err := newStorages(conf, awsConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := fmt.Sprintf("%x", sha256.Sum256([]byte(teamName))) + "/"
+	cutoff := ""
+	if retention.Days > 0 {
+		cutoff = time.Now().UTC().AddDate(0, 0, -retention.Days).Format("2006-01-02")
+	}
+
+	buckets := []struct {
+		name    string
+		store   storage.Storage
+		folders map[string][]string
+	}{
+		{name: conf.S3.PrivateBucket, store: private},
+		{name: conf.S3.PublicBucket, store: public},
+	}
+	// A report is made of files in both buckets, so the expired reports are
+	// computed from the folders of both, and a report is deleted from both
+	// buckets or from none.
+	all := make(map[string][]string)
+	for i, b := range buckets {
+		keys, err := b.store.List(ctx, prefix)
+		if err != nil {
+			return nil, err
+		}
+		buckets[i].folders = reportFolders(prefix, keys)
+		for date, keys := range buckets[i].folders {
+			all[date] = append(all[date], keys...)
+		}
+	}
+
+	var expired []ExpiredFile
+	for _, date := range expiredDates(all, retention.Keep, cutoff) {
+		for _, b := range buckets {
+			keys, ok := b.folders[date]
+			if !ok {
+				continue
+			}
+			if dryRun {
+				log.Printf("would delete the report of %s from %s", date, b.name)
+			} else {
+				log.Printf("deleting the report of %s from %s", date, b.name)
+			}
+			for _, key := range keys {
+				if !dryRun {
+					if err := b.store.Delete(ctx, key); err != nil {
+						return nil, err
+					}
+				}
+				expired = append(expired, ExpiredFile{Bucket: b.name, Key: key})
+			}
+		}
+	}
+
+	// The parts of the uploads interrupted by killed processes are also
+	// stored in the buckets.
+	for _, b := range buckets {
+		s3Store, ok := b.store.(*storage.S3Storage)
+		if !ok {
+			continue
+		}
+		if err := abortIncompleteUploads(ctx, s3Store, b.name, prefix, dryRun); err != nil {
+			return nil, err
+		}
+	}
+	return expired, nil
+}
+
+// staleUploadAge is the age of the incomplete uploads aborted with the
+// expired reports. The younger ones may still be in progress.
+const staleUploadAge = 24 * time.Hour
+
+// abortIncompleteUploads aborts the stale incomplete uploads of the files
+// with the given prefix. In dry-run mode they are only logged.
+func abortIncompleteUploads(ctx context.Context, store *storage.S3Storage, bucket, prefix string, dryRun bool) error {
+	uploads, err := store.IncompleteUploads(ctx, prefix, time.Now().Add(-staleUploadAge))
+	if err != nil {
+		return fmt.Errorf("error listing the incomplete uploads of %s: %w", bucket, err)
+	}
+	for _, u := range uploads {
+		if dryRun {
+			log.Printf("would abort the incomplete upload of %s from %s", u.Key, bucket)
+			continue
+		}
+		log.Printf("aborting the incomplete upload of %s from %s", u.Key, bucket)
+		if err := store.AbortUpload(ctx, u); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reportFolders groups the keys of the files of the reports of a team by the
+// date of the report. The keys not stored in a folder named after a date are
+// ignored.
+func reportFolders(prefix string, keys []string) map[string][]string {
+	folders := make(map[string][]string)
+	for _, key := range keys {
+		date, _, ok := strings.Cut(strings.TrimPrefix(key, prefix), "/")
+		if !ok {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			continue
+		}
+		folders[date] = append(folders[date], key)
+	}
+	return folders
+}
+
+// expiredDates returns the dates of the reports that are neither in the last
+// keep ones nor published on or after the cutoff date, if any.
+func expiredDates(folders map[string][]string, keep int, cutoff string) []string {
+	dates := make([]string, 0, len(folders))
+	for date := range folders {
+		dates = append(dates, date)
+	}
+	// The dates have the format YYYY-MM-DD, so they are sorted from the
+	// newest to the oldest as strings.
+	sort.Sort(sort.Reverse(sort.StringSlice(dates)))
+
+	var expired []string
+	for i, date := range dates {
+		if i < keep || (cutoff != "" && date >= cutoff) {
+			continue
+		}
+		expired = append(expired, date)
+	}
+	return expired
+}
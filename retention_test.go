@@ -0,0 +1,53 @@
+package insights
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReportFolders(t *testing.T) {
+	keys := []string{
+		"abc/2022-10-01/report.html",
+		"abc/2022-10-01/data.json",
+		"abc/2022-10-08/report.html",
+		"abc/history.json",
+		"abc/latest/report.html",
+	}
+	want := map[string][]string{
+		"2022-10-01": {"abc/2022-10-01/report.html", "abc/2022-10-01/data.json"},
+		"2022-10-08": {"abc/2022-10-08/report.html"},
+	}
+	if got := reportFolders("abc/", keys); !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected folders: got %v, want %v", got, want)
+	}
+}
+
+func TestExpiredDates(t *testing.T) {
+	folders := map[string][]string{
+		"2022-10-01": nil,
+		"2022-10-08": nil,
+		"2022-10-15": nil,
+		"2022-10-22": nil,
+	}
+
+	tests := []struct {
+		name   string
+		keep   int
+		cutoff string
+		want   []string
+	}{
+		{name: "keep", keep: 2, want: []string{"2022-10-08", "2022-10-01"}},
+		{name: "keep more than published", keep: 10, want: nil},
+		{name: "cutoff", cutoff: "2022-10-08", want: []string{"2022-10-01"}},
+		{name: "keep and cutoff", keep: 1, cutoff: "2022-10-15", want: []string{"2022-10-08", "2022-10-01"}},
+		{name: "cutoff keeping more", keep: 1, cutoff: "2022-10-08", want: []string{"2022-10-01"}},
+		{name: "nothing kept", want: []string{"2022-10-22", "2022-10-15", "2022-10-08", "2022-10-01"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := expiredDates(folders, tt.keep, tt.cutoff); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("unexpected dates: got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
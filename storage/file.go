@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// FileStorage stores the files in a local directory, for instance the root of
+// a web server publishing them under BaseURL. If BaseURL is empty the URLs of
+// the files are their paths.
+type FileStorage struct {
+	Dir     string
+	BaseURL string
+}
+
+// Put writes the content to the file, creating its directory if needed.
+func (s *FileStorage) Put(ctx context.Context, key string, content []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	p := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(p, content, 0600)
+}
+
+// Get reads the content of the file.
+func (s *FileStorage) Get(ctx context.Context, key string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return os.ReadFile(s.path(key))
+}
+
+// URL returns the URL of the file under the base URL or, if it is empty, its
+// path.
+func (s *FileStorage) URL(key string) string {
+	if s.BaseURL == "" {
+		return s.path(key)
+	}
+	return joinURL(s.BaseURL, key)
+}
+
+// List walks the directory looking for the files with the given prefix.
+func (s *FileStorage) List(ctx context.Context, prefix string) ([]string, error) {
+	keys := []string{}
+	err := filepath.WalkDir(s.Dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(s.Dir, p)
+		if err != nil {
+			return err
+		}
+		if key := filepath.ToSlash(rel); strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// Delete removes the file and the directories left empty.
+func (s *FileStorage) Delete(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	err := os.Remove(s.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	// Removing a directory fails if it is not empty.
+	for dir := path.Dir(key); dir != "."; dir = path.Dir(dir) {
+		if os.Remove(s.path(dir)) != nil {
+			break
+		}
+	}
+	return nil
+}
+
+func (s *FileStorage) path(key string) string {
+	return filepath.Join(s.Dir, filepath.FromSlash(key))
+}
@@ -0,0 +1,137 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// newTestFileStorage returns a FileStorage in a temporary directory with the
+// given files, whose content is their key.
+func newTestFileStorage(t *testing.T, keys ...string) *FileStorage {
+	s := &FileStorage{Dir: filepath.Join(t.TempDir(), "files")}
+	for _, key := range keys {
+		if err := s.Put(context.Background(), key, []byte(key)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return s
+}
+
+func TestFileStorageList(t *testing.T) {
+	keys := []string{"team/scan/report.html", "team/scan/charts/risk.png", "team/report.json", "other/report.json"}
+	tests := []struct {
+		name   string
+		keys   []string
+		prefix string
+		want   []string
+	}{
+		{name: "all", keys: keys, prefix: "", want: []string{"other/report.json", "team/report.json", "team/scan/charts/risk.png", "team/scan/report.html"}},
+		{name: "folder", keys: keys, prefix: "team/scan/", want: []string{"team/scan/charts/risk.png", "team/scan/report.html"}},
+		{name: "partial name", keys: keys, prefix: "team/rep", want: []string{"team/report.json"}},
+		{name: "no match", keys: keys, prefix: "unknown/", want: []string{}},
+		{name: "missing directory", prefix: "", want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestFileStorage(t, tt.keys...)
+			got, err := s.List(context.Background(), tt.prefix)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("unexpected keys: got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFileStorageDelete(t *testing.T) {
+	tests := []struct {
+		name     string
+		keys     []string
+		key      string
+		wantKeys []string
+		wantDirs []string
+		goneDirs []string
+	}{
+		{
+			name:     "empty directories",
+			keys:     []string{"team/scan/charts/risk.png", "other/report.json"},
+			key:      "team/scan/charts/risk.png",
+			wantKeys: []string{"other/report.json"},
+			wantDirs: []string{"other"},
+			goneDirs: []string{"team/scan/charts", "team/scan", "team"},
+		},
+		{
+			name:     "non-empty directories",
+			keys:     []string{"team/scan/charts/risk.png", "team/scan/report.html"},
+			key:      "team/scan/charts/risk.png",
+			wantKeys: []string{"team/scan/report.html"},
+			wantDirs: []string{"team/scan", "team"},
+			goneDirs: []string{"team/scan/charts"},
+		},
+		{
+			name:     "missing file",
+			keys:     []string{"team/report.json"},
+			key:      "team/missing.json",
+			wantKeys: []string{"team/report.json"},
+			wantDirs: []string{"team"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestFileStorage(t, tt.keys...)
+			if err := s.Delete(context.Background(), tt.key); err != nil {
+				t.Fatal(err)
+			}
+			got, err := s.List(context.Background(), "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.wantKeys) {
+				t.Errorf("unexpected keys: got %v, want %v", got, tt.wantKeys)
+			}
+			for _, dir := range tt.wantDirs {
+				if _, err := os.Stat(s.path(dir)); err != nil {
+					t.Errorf("unexpected error for directory %s: %v", dir, err)
+				}
+			}
+			for _, dir := range tt.goneDirs {
+				if _, err := os.Stat(s.path(dir)); !errors.Is(err, fs.ErrNotExist) {
+					t.Errorf("directory %s not removed: %v", dir, err)
+				}
+			}
+			// The directory of the storage is never removed.
+			if _, err := os.Stat(s.Dir); err != nil {
+				t.Errorf("unexpected error for the storage directory: %v", err)
+			}
+		})
+	}
+}
+
+func TestFileStorageURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		dir     string
+		baseURL string
+		key     string
+		want    string
+	}{
+		{name: "path", dir: "/var/www", key: "team/report.html", want: filepath.Join("/var/www", "team", "report.html")},
+		{name: "base URL", dir: "/var/www", baseURL: "https://reports.example.com", key: "team/report.html", want: "https://reports.example.com/team/report.html"},
+		{name: "base URL with slash", dir: "/var/www", baseURL: "https://reports.example.com/", key: "team/report.html", want: "https://reports.example.com/team/report.html"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &FileStorage{Dir: tt.dir, BaseURL: tt.baseURL}
+			if got := s.URL(tt.key); got != tt.want {
+				t.Errorf("unexpected URL: got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
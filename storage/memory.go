@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// MemStorage stores the files in memory, so the reports can be generated
+// without publishing them. The files are published under BaseURL. It is safe
+// for concurrent use.
+type MemStorage struct {
+	BaseURL string
+
+	mu    sync.Mutex
+	files map[string][]byte
+}
+
+// Put stores a copy of the content.
+func (s *MemStorage) Put(ctx context.Context, key string, content []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.files == nil {
+		s.files = make(map[string][]byte)
+	}
+	s.files[key] = append([]byte(nil), content...)
+	return nil
+}
+
+// Get returns a copy of the content stored in the given key.
+func (s *MemStorage) Get(ctx context.Context, key string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	content, ok := s.files[key]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", key, fs.ErrNotExist)
+	}
+	return append([]byte(nil), content...), nil
+}
+
+// URL returns the URL of the file under the base URL.
+func (s *MemStorage) URL(key string) string {
+	return joinURL(s.BaseURL, key)
+}
+
+// List returns the keys of the files stored with the given prefix.
+func (s *MemStorage) List(ctx context.Context, prefix string) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := []string{}
+	for key := range s.files {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// Delete removes the file.
+func (s *MemStorage) Delete(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.files, key)
+	return nil
+}
@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"reflect"
+	"testing"
+)
+
+// newTestMemStorage returns a MemStorage with the given files, whose content
+// is their key.
+func newTestMemStorage(t *testing.T, keys ...string) *MemStorage {
+	s := &MemStorage{}
+	for _, key := range keys {
+		if err := s.Put(context.Background(), key, []byte(key)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return s
+}
+
+func TestMemStorageList(t *testing.T) {
+	keys := []string{"team/scan/report.html", "team/scan/charts/risk.png", "team/report.json", "other/report.json"}
+	tests := []struct {
+		name   string
+		keys   []string
+		prefix string
+		want   []string
+	}{
+		{name: "all", keys: keys, prefix: "", want: []string{"other/report.json", "team/report.json", "team/scan/charts/risk.png", "team/scan/report.html"}},
+		{name: "folder", keys: keys, prefix: "team/scan/", want: []string{"team/scan/charts/risk.png", "team/scan/report.html"}},
+		{name: "partial name", keys: keys, prefix: "team/rep", want: []string{"team/report.json"}},
+		{name: "no match", keys: keys, prefix: "unknown/", want: []string{}},
+		{name: "empty storage", prefix: "", want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestMemStorage(t, tt.keys...)
+			got, err := s.List(context.Background(), tt.prefix)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("unexpected keys: got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemStorageDelete(t *testing.T) {
+	tests := []struct {
+		name     string
+		keys     []string
+		key      string
+		wantKeys []string
+	}{
+		{name: "existing file", keys: []string{"team/scan/report.html", "team/report.json"}, key: "team/scan/report.html", wantKeys: []string{"team/report.json"}},
+		{name: "missing file", keys: []string{"team/report.json"}, key: "team/missing.json", wantKeys: []string{"team/report.json"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestMemStorage(t, tt.keys...)
+			if err := s.Delete(context.Background(), tt.key); err != nil {
+				t.Fatal(err)
+			}
+			got, err := s.List(context.Background(), "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.wantKeys) {
+				t.Errorf("unexpected keys: got %v, want %v", got, tt.wantKeys)
+			}
+			if _, err := s.Get(context.Background(), tt.key); !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("unexpected error: got %v, want %v", err, fs.ErrNotExist)
+			}
+		})
+	}
+}
+
+func TestMemStorageURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		baseURL string
+		key     string
+		want    string
+	}{
+		{name: "no base URL", key: "team/report.html", want: "team/report.html"},
+		{name: "base URL", baseURL: "https://reports.example.com", key: "team/report.html", want: "https://reports.example.com/team/report.html"},
+		{name: "base URL with slash", baseURL: "https://reports.example.com/", key: "team/report.html", want: "https://reports.example.com/team/report.html"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &MemStorage{BaseURL: tt.baseURL}
+			if got := s.URL(tt.key); got != tt.want {
+				t.Errorf("unexpected URL: got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
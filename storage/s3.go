@@ -0,0 +1,348 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+)
+
+// S3Storage stores the files in a S3 bucket. They are published under
+// BaseURL, for instance the endpoint of a proxy in front of the bucket or, if
+// Expiry is not zero, with pre-signed URLs valid for that time.
+//
+// The files are uploaded in parts of PartSize bytes, Concurrency of them in
+// parallel, with the given Cache-Control and server-side encryption, SSE, that
+// can be AES256 or aws:kms with the key SSEKMSKeyID. If ContentEncoding is
+// gzip the text files are compressed. The zero values use the defaults of the
+// SDK and of the bucket. The failed multipart uploads are aborted, even if
+// they were canceled.
+type S3Storage struct {
+	Client  s3iface.S3API
+	Bucket  string
+	BaseURL string
+	Expiry  time.Duration
+
+	PartSize        int64
+	Concurrency     int
+	CacheControl    string
+	ContentEncoding string
+	SSE             string
+	SSEKMSKeyID     string
+}
+
+// metadataMD5 is the metadata of the objects with the MD5 of their content,
+// used to skip the upload of the objects that did not change. Unlike the ETag
+// it does not depend on the encryption and on the parts of the upload.
+const metadataMD5 = "Md5"
+
+// Put uploads the content to the bucket, unless the object already has the
+// same content, and verifies the upload with the MD5 of the content.
+func (s *S3Storage) Put(ctx context.Context, key string, content []byte) error {
+	input := &s3manager.UploadInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(key),
+	}
+	if ct := contentType(key); ct != "" {
+		input.ContentType = aws.String(ct)
+	}
+	if s.CacheControl != "" {
+		input.CacheControl = aws.String(s.CacheControl)
+	}
+	if s.ContentEncoding != "" && compressible(key) {
+		var err error
+		content, err = encode(s.ContentEncoding, content)
+		if err != nil {
+			return err
+		}
+		input.ContentEncoding = aws.String(s.ContentEncoding)
+	}
+	if s.SSE != "" {
+		input.ServerSideEncryption = aws.String(s.SSE)
+	}
+	if s.SSEKMSKeyID != "" {
+		input.SSEKMSKeyId = aws.String(s.SSEKMSKeyID)
+	}
+
+	sum := md5.Sum(content)
+	digest := hex.EncodeToString(sum[:])
+	if s.unchanged(ctx, input, digest) {
+		return nil
+	}
+	input.Metadata = map[string]*string{metadataMD5: aws.String(digest)}
+	// The MD5 is only verified by S3 if the file is uploaded in a single
+	// part, so the ETag of the object is also checked.
+	input.ContentMD5 = aws.String(base64.StdEncoding.EncodeToString(sum[:]))
+	input.Body = bytes.NewReader(content)
+
+	uploader := s3manager.NewUploaderWithClient(s.Client, func(u *s3manager.Uploader) {
+		if s.PartSize > 0 {
+			u.PartSize = s.PartSize
+		}
+		if s.Concurrency > 0 {
+			u.Concurrency = s.Concurrency
+		}
+		// The SDK aborts the failed multipart uploads with the context of
+		// the upload, which fails if it was canceled, so they are aborted
+		// by abortUpload instead.
+		u.LeavePartsOnError = true
+	})
+	out, err := uploader.UploadWithContext(ctx, input)
+	if err != nil {
+		s.abortUpload(key, err)
+		return err
+	}
+
+	if out.ETag == nil {
+		return nil
+	}
+	got, want := strings.Trim(*out.ETag, `"`), etag(content, uploader.PartSize)
+	if got == want {
+		return nil
+	}
+	// The ETag of the objects encrypted with KMS, either requested or by
+	// default in the bucket, is not derived from their content. The upload
+	// response does not contain the encryption, so it is requested.
+	head, err := s.Client.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return err
+	}
+	if kmsEncrypted(aws.StringValue(head.ServerSideEncryption)) {
+		return nil
+	}
+	return fmt.Errorf("invalid ETag of %s: got %s, want %s", key, got, want)
+}
+
+// abortTimeout is the time given to abort a failed multipart upload.
+const abortTimeout = 30 * time.Second
+
+// abortUpload aborts the multipart upload that failed with the given error, if
+// any, so its parts are not left in the bucket when the upload is interrupted.
+func (s *S3Storage) abortUpload(key string, err error) {
+	var failure s3manager.MultiUploadFailure
+	if !errors.As(err, &failure) || failure.UploadID() == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), abortTimeout)
+	defer cancel()
+	if err := s.AbortUpload(ctx, IncompleteUpload{Key: key, UploadID: failure.UploadID()}); err != nil {
+		log.Printf("error aborting the upload of %s: %v", key, err)
+	}
+}
+
+// IncompleteUpload is a multipart upload that was neither completed nor
+// aborted, for instance because the process uploading it was killed. Its
+// parts are stored, and billed, until it is aborted.
+type IncompleteUpload struct {
+	Key       string
+	UploadID  string
+	Initiated time.Time
+}
+
+// IncompleteUploads returns the multipart uploads of the objects with the
+// given prefix initiated before the given time.
+func (s *S3Storage) IncompleteUploads(ctx context.Context, prefix string, before time.Time) ([]IncompleteUpload, error) {
+	var uploads []IncompleteUpload
+	input := &s3.ListMultipartUploadsInput{
+		Bucket: aws.String(s.Bucket),
+		Prefix: aws.String(prefix),
+	}
+	err := s.Client.ListMultipartUploadsPagesWithContext(ctx, input, func(page *s3.ListMultipartUploadsOutput, last bool) bool {
+		for _, u := range page.Uploads {
+			initiated := aws.TimeValue(u.Initiated)
+			if !initiated.Before(before) {
+				continue
+			}
+			uploads = append(uploads, IncompleteUpload{
+				Key:       aws.StringValue(u.Key),
+				UploadID:  aws.StringValue(u.UploadId),
+				Initiated: initiated,
+			})
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return uploads, nil
+}
+
+// AbortUpload aborts the incomplete upload, deleting its parts.
+func (s *S3Storage) AbortUpload(ctx context.Context, u IncompleteUpload) error {
+	_, err := s.Client.AbortMultipartUploadWithContext(ctx, &s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(s.Bucket),
+		Key:      aws.String(u.Key),
+		UploadId: aws.String(u.UploadID),
+	})
+	return err
+}
+
+// kmsEncrypted returns true if the server-side encryption uses KMS keys,
+// including the dual-layer encryption, aws:kms:dsse.
+func kmsEncrypted(sse string) bool {
+	return strings.HasPrefix(sse, s3.ServerSideEncryptionAwsKms)
+}
+
+// unchanged returns true if the object exists, has the content with the given
+// MD5 and the headers and encryption of the upload. The encryption is only
+// compared if it is configured, as the one of the bucket may be used
+// otherwise. Any error is considered a change, so the object is uploaded.
+func (s *S3Storage) unchanged(ctx context.Context, input *s3manager.UploadInput, digest string) bool {
+	out, err := s.Client.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+		Bucket: input.Bucket,
+		Key:    input.Key,
+	})
+	if err != nil {
+		return false
+	}
+	if aws.StringValue(out.Metadata[metadataMD5]) != digest ||
+		aws.StringValue(out.ContentType) != aws.StringValue(input.ContentType) ||
+		aws.StringValue(out.CacheControl) != aws.StringValue(input.CacheControl) ||
+		aws.StringValue(out.ContentEncoding) != aws.StringValue(input.ContentEncoding) {
+		return false
+	}
+	if s.SSE != "" && aws.StringValue(out.ServerSideEncryption) != s.SSE {
+		return false
+	}
+	// S3 returns the ARN of the KMS key, which ends with the ID of the key.
+	if id := aws.StringValue(out.SSEKMSKeyId); s.SSEKMSKeyID != "" && id != s.SSEKMSKeyID && !strings.HasSuffix(id, "/"+s.SSEKMSKeyID) {
+		return false
+	}
+	return true
+}
+
+// etag returns the ETag S3 assigns to an object with the given content
+// uploaded in parts of the given size: the MD5 of the content if it is
+// uploaded in a single part and the MD5 of the MD5s of the parts, followed by
+// the number of parts, otherwise.
+func etag(content []byte, partSize int64) string {
+	if int64(len(content)) <= partSize {
+		sum := md5.Sum(content)
+		return hex.EncodeToString(sum[:])
+	}
+	var sums []byte
+	n := 0
+	for start := int64(0); start < int64(len(content)); start += partSize {
+		end := start + partSize
+		if end > int64(len(content)) {
+			end = int64(len(content))
+		}
+		sum := md5.Sum(content[start:end])
+		sums = append(sums, sum[:]...)
+		n++
+	}
+	sum := md5.Sum(sums)
+	return fmt.Sprintf("%s-%d", hex.EncodeToString(sum[:]), n)
+}
+
+// Get downloads the content of the object, decompressing it if needed.
+func (s *S3Storage) Get(ctx context.Context, key string) ([]byte, error) {
+	out, err := s.Client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer out.Body.Close()
+	content, err := io.ReadAll(out.Body)
+	if err != nil {
+		return nil, err
+	}
+	// The HTTP client decompresses transparently the content it requested
+	// compressed, removing its Content-Encoding.
+	if encoding := aws.StringValue(out.ContentEncoding); encoding != "" {
+		return decode(encoding, content)
+	}
+	return content, nil
+}
+
+// URL returns the pre-signed URL of the object or, if the URLs are not
+// signed, its URL under the base URL. If the URL can not be signed the error
+// is logged and the URL under the base URL is returned.
+func (s *S3Storage) URL(key string) string {
+	if s.Expiry > 0 {
+		req, _ := s.Client.GetObjectRequest(&s3.GetObjectInput{
+			Bucket: aws.String(s.Bucket),
+			Key:    aws.String(key),
+		})
+		u, err := req.Presign(s.Expiry)
+		if err == nil {
+			return u
+		}
+		log.Printf("error signing the URL of %s: %v", key, err)
+	}
+	return joinURL(s.BaseURL, key)
+}
+
+// List returns the keys of the objects of the bucket with the given prefix.
+func (s *S3Storage) List(ctx context.Context, prefix string) ([]string, error) {
+	keys := []string{}
+	input := &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.Bucket),
+		Prefix: aws.String(prefix),
+	}
+	err := s.Client.ListObjectsV2PagesWithContext(ctx, input, func(page *s3.ListObjectsV2Output, last bool) bool {
+		for _, o := range page.Contents {
+			keys = append(keys, aws.StringValue(o.Key))
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// Delete removes the object from the bucket.
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	_, err := s.Client.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(key),
+	})
+	return err
+}
+
+// S3BucketURL returns the URL the objects of a bucket are accessed directly
+// under. With the default endpoint it is the regional endpoint of S3, using
+// virtual-hosted or path style addressing, and with a custom endpoint, for
+// instance the one of a MinIO server, the bucket is either added to its host
+// or to its path.
+func S3BucketURL(region, endpoint, bucket string, pathStyle bool) string {
+	if endpoint == "" {
+		if pathStyle {
+			return fmt.Sprintf("https://s3.%s.amazonaws.com/%s", region, bucket)
+		}
+		return fmt.Sprintf("https://%s.s3.%s.amazonaws.com", bucket, region)
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		// The SDK accepts endpoints without scheme and uses HTTPS for them.
+		u, err = url.Parse("https://" + endpoint)
+		if err != nil {
+			return joinURL(endpoint, bucket)
+		}
+	}
+	if pathStyle {
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/" + bucket
+	} else {
+		u.Host = bucket + "." + u.Host
+	}
+	return strings.TrimSuffix(u.String(), "/")
+}
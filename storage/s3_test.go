@@ -0,0 +1,303 @@
+package storage
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+)
+
+func TestS3BucketURL(t *testing.T) {
+	tests := []struct {
+		name      string
+		region    string
+		endpoint  string
+		pathStyle bool
+		want      string
+	}{
+		{name: "virtual-hosted", region: "eu-west-1", want: "https://reports.s3.eu-west-1.amazonaws.com"},
+		{name: "path style", region: "eu-west-1", pathStyle: true, want: "https://s3.eu-west-1.amazonaws.com/reports"},
+		{name: "endpoint", endpoint: "http://localhost:9000", want: "http://reports.localhost:9000"},
+		{name: "endpoint with path style", endpoint: "http://localhost:9000/", pathStyle: true, want: "http://localhost:9000/reports"},
+		{name: "endpoint with path", endpoint: "https://storage.example.com/s3/", pathStyle: true, want: "https://storage.example.com/s3/reports"},
+		{name: "endpoint without scheme", endpoint: "storage.example.com", want: "https://reports.storage.example.com"},
+		{name: "endpoint without scheme with path style", endpoint: "storage.example.com:9000", pathStyle: true, want: "https://storage.example.com:9000/reports"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := S3BucketURL(tt.region, tt.endpoint, "reports", tt.pathStyle); got != tt.want {
+				t.Errorf("unexpected URL: got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestETag(t *testing.T) {
+	md5hex := func(b []byte) string {
+		sum := md5.Sum(b)
+		return hex.EncodeToString(sum[:])
+	}
+	content := []byte("0123456789")
+	parts := func(sizes ...int) string {
+		var sums []byte
+		start := 0
+		for _, n := range sizes {
+			sum := md5.Sum(content[start : start+n])
+			sums = append(sums, sum[:]...)
+			start += n
+		}
+		return md5hex(sums)
+	}
+
+	tests := []struct {
+		name     string
+		partSize int64
+		want     string
+	}{
+		{name: "single part", partSize: 100, want: md5hex(content)},
+		{name: "exact part", partSize: 10, want: md5hex(content)},
+		{name: "parts", partSize: 5, want: parts(5, 5) + "-2"},
+		{name: "last part smaller", partSize: 4, want: parts(4, 4, 2) + "-3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := etag(content, tt.partSize); got != tt.want {
+				t.Errorf("unexpected ETag: got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKMSEncrypted(t *testing.T) {
+	tests := []struct {
+		sse  string
+		want bool
+	}{
+		{sse: "", want: false},
+		{sse: "AES256", want: false},
+		{sse: "aws:kms", want: true},
+		{sse: "aws:kms:dsse", want: true},
+	}
+	for _, tt := range tests {
+		if got := kmsEncrypted(tt.sse); got != tt.want {
+			t.Errorf("unexpected result of %q: got %v, want %v", tt.sse, got, tt.want)
+		}
+	}
+}
+
+// fakeS3 is a S3 server keeping a single object, whose ETag and encryption
+// can be forced to test the verification of the uploads.
+type fakeS3 struct {
+	headers http.Header // Headers of the stored object, nil if none.
+	etag    string      // ETag returned by the uploads, the MD5 if empty.
+	sse     string      // Encryption of the uploaded objects.
+	puts    int
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodHead:
+		if f.headers == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		for k, v := range f.headers {
+			w.Header()[k] = v
+		}
+	case http.MethodPut:
+		f.puts++
+		body, _ := io.ReadAll(r.Body)
+		f.headers = http.Header{}
+		for k, v := range r.Header {
+			if strings.HasPrefix(k, "X-Amz-Meta-") || k == "Content-Type" || k == "Cache-Control" || k == "Content-Encoding" {
+				f.headers[k] = v
+			}
+		}
+		if f.sse != "" {
+			f.headers.Set("X-Amz-Server-Side-Encryption", f.sse)
+		}
+		etag := f.etag
+		if etag == "" {
+			sum := md5.Sum(body)
+			etag = hex.EncodeToString(sum[:])
+		}
+		w.Header().Set("ETag", `"`+etag+`"`)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func newTestS3Storage(t *testing.T, f *fakeS3) *S3Storage {
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	sess, err := session.NewSession(&aws.Config{
+		Region:           aws.String("eu-west-1"),
+		Endpoint:         aws.String(srv.URL),
+		S3ForcePathStyle: aws.Bool(true),
+		Credentials:      credentials.NewStaticCredentials("id", "secret", ""),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &S3Storage{Client: s3.New(sess), Bucket: "reports", CacheControl: "no-cache"}
+}
+
+func TestS3StoragePut(t *testing.T) {
+	tests := []struct {
+		name     string
+		fake     fakeS3
+		changeCC bool // Change the Cache-Control between the uploads.
+		wantErr  bool
+		wantPuts int
+	}{
+		{name: "unchanged", wantPuts: 1},
+		{name: "changed headers", changeCC: true, wantPuts: 2},
+		{name: "invalid ETag", fake: fakeS3{etag: "bad"}, wantErr: true, wantPuts: 1},
+		{name: "KMS encryption", fake: fakeS3{etag: "kms", sse: "aws:kms"}, wantPuts: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			f := tt.fake
+			s := newTestS3Storage(t, &f)
+			err := s.Put(ctx, "report.html", []byte("<html></html>"))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err != nil {
+				if f.puts != tt.wantPuts {
+					t.Errorf("unexpected uploads: got %d, want %d", f.puts, tt.wantPuts)
+				}
+				return
+			}
+
+			// The same content is only uploaded again if the headers
+			// changed.
+			if tt.changeCC {
+				s.CacheControl = "max-age=60"
+			}
+			if err := s.Put(ctx, "report.html", []byte("<html></html>")); err != nil {
+				t.Fatal(err)
+			}
+			if f.puts != tt.wantPuts {
+				t.Errorf("unexpected uploads: got %d, want %d", f.puts, tt.wantPuts)
+			}
+		})
+	}
+}
+
+// fakeMultipartS3 is a S3 server accepting multipart uploads. The upload of
+// the first part calls onPart, and the aborted uploads are recorded.
+type fakeMultipartS3 struct {
+	onPart  func()
+	uploads string // Body of the responses listing the multipart uploads.
+
+	mu      sync.Mutex
+	aborted []string
+}
+
+func (f *fakeMultipartS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	switch {
+	case r.Method == http.MethodHead:
+		w.WriteHeader(http.StatusNotFound)
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		fmt.Fprint(w, `<InitiateMultipartUploadResult><Bucket>reports</Bucket><Key>report.json</Key><UploadId>u1</UploadId></InitiateMultipartUploadResult>`)
+	case r.Method == http.MethodPut && query.Has("partNumber"):
+		io.Copy(io.Discard, r.Body)
+		f.mu.Lock()
+		onPart := f.onPart
+		f.onPart = nil
+		f.mu.Unlock()
+		if onPart != nil {
+			onPart()
+		}
+		w.Header().Set("ETag", `"part"`)
+	case r.Method == http.MethodDelete && query.Has("uploadId"):
+		f.mu.Lock()
+		f.aborted = append(f.aborted, strings.TrimPrefix(r.URL.Path, "/reports/")+"#"+query.Get("uploadId"))
+		f.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodGet && query.Has("uploads"):
+		fmt.Fprint(w, f.uploads)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func newTestMultipartS3Storage(t *testing.T, f *fakeMultipartS3) *S3Storage {
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	sess, err := session.NewSession(&aws.Config{
+		Region:           aws.String("eu-west-1"),
+		Endpoint:         aws.String(srv.URL),
+		S3ForcePathStyle: aws.Bool(true),
+		Credentials:      credentials.NewStaticCredentials("id", "secret", ""),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &S3Storage{Client: s3.New(sess), Bucket: "reports", PartSize: s3manager.MinUploadPartSize, Concurrency: 1}
+}
+
+func TestS3StoragePutCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// The upload is interrupted after its first part.
+	f := &fakeMultipartS3{onPart: cancel}
+	s := newTestMultipartS3Storage(t, f)
+
+	content := make([]byte, 3*s3manager.MinUploadPartSize)
+	if err := s.Put(ctx, "report.json", content); err == nil {
+		t.Fatal("expected error")
+	}
+
+	// The parts already uploaded are deleted even though the context of the
+	// upload is canceled.
+	want := []string{"report.json#u1"}
+	if !reflect.DeepEqual(f.aborted, want) {
+		t.Errorf("unexpected aborted uploads: got %v, want %v", f.aborted, want)
+	}
+}
+
+func TestS3StorageIncompleteUploads(t *testing.T) {
+	f := &fakeMultipartS3{uploads: `<ListMultipartUploadsResult>
+<Bucket>reports</Bucket>
+<Upload><Key>team/2022-10-10/report.json</Key><UploadId>old</UploadId><Initiated>2022-10-10T10:00:00.000Z</Initiated></Upload>
+<Upload><Key>team/2022-10-12/report.json</Key><UploadId>new</UploadId><Initiated>2022-10-12T10:00:00.000Z</Initiated></Upload>
+</ListMultipartUploadsResult>`}
+	s := newTestMultipartS3Storage(t, f)
+	ctx := context.Background()
+
+	// Only the uploads initiated before the given time are returned.
+	before := time.Date(2022, 10, 11, 0, 0, 0, 0, time.UTC)
+	uploads, err := s.IncompleteUploads(ctx, "team/", before)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []IncompleteUpload{{Key: "team/2022-10-10/report.json", UploadID: "old", Initiated: time.Date(2022, 10, 10, 10, 0, 0, 0, time.UTC)}}
+	if !reflect.DeepEqual(uploads, want) {
+		t.Fatalf("unexpected uploads: got %+v, want %+v", uploads, want)
+	}
+
+	if err := s.AbortUpload(ctx, uploads[0]); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"team/2022-10-10/report.json#old"}; !reflect.DeepEqual(f.aborted, want) {
+		t.Errorf("unexpected aborted uploads: got %v, want %v", f.aborted, want)
+	}
+}
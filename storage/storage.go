@@ -0,0 +1,109 @@
+// Package storage provides the stores where the files of the reports are
+// published.
+package storage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"path"
+	"strings"
+)
+
+// Types of storage.
+const (
+	TypeS3     = "s3"
+	TypeDir    = "dir"
+	TypeMemory = "memory"
+)
+
+// EncodingGzip is the content encoding of the files compressed with gzip.
+const EncodingGzip = "gzip"
+
+// Storage stores the files of the reports, identified by keys with the format
+// <folder>/<filename>, and publishes them.
+type Storage interface {
+	// Put stores the content in the given key, replacing the previous
+	// content, if any.
+	Put(ctx context.Context, key string, content []byte) error
+	// Get returns the content stored in the given key.
+	Get(ctx context.Context, key string) ([]byte, error)
+	// URL returns the URL where the file stored in the given key is
+	// published.
+	URL(key string) string
+	// List returns the keys of the files whose key starts with the given
+	// prefix, sorted.
+	List(ctx context.Context, prefix string) ([]string, error)
+	// Delete removes the file stored in the given key. It does not fail if
+	// the file does not exist.
+	Delete(ctx context.Context, key string) error
+}
+
+// contentType returns the MIME type of a file according to the extension of
+// its key.
+func contentType(key string) string {
+	return mime.TypeByExtension(path.Ext(key))
+}
+
+// joinURL returns the URL of a key published under the given base URL, or the
+// key itself if the base URL is empty.
+func joinURL(baseURL, key string) string {
+	if baseURL == "" {
+		return key
+	}
+	return strings.TrimSuffix(baseURL, "/") + "/" + key
+}
+
+// compressible returns true if the file with the given key is a text file
+// worth compressing.
+func compressible(key string) bool {
+	switch path.Ext(key) {
+	case ".html", ".js", ".json", ".css":
+		return true
+	default:
+		return false
+	}
+}
+
+// CheckEncoding returns an error if the content encoding is not supported.
+// An empty encoding means no encoding.
+func CheckEncoding(encoding string) error {
+	switch encoding {
+	case "", EncodingGzip:
+		return nil
+	default:
+		return fmt.Errorf("unsupported content encoding: %s", encoding)
+	}
+}
+
+// encode compresses the content with the given encoding.
+func encode(encoding string, content []byte) ([]byte, error) {
+	if encoding != EncodingGzip {
+		return nil, fmt.Errorf("unsupported content encoding: %s", encoding)
+	}
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(content); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decode decompresses the content with the given encoding.
+func decode(encoding string, content []byte) ([]byte, error) {
+	if encoding != EncodingGzip {
+		return nil, fmt.Errorf("unsupported content encoding: %s", encoding)
+	}
+	r, err := gzip.NewReader(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
@@ -0,0 +1,370 @@
+package vulcan
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/adevinta/vulcan-groupie/pkg/groupie"
+	vulcanreport "github.com/adevinta/vulcan-report"
+)
+
+// aggregator builds the data of a report incrementally, as the reports of the
+// checks are retrieved, so the reports do not need to be kept in memory. The
+// memory it uses is bounded by the unique findings of the scan, of which only
+// the fields needed by the report data and the grouping database are kept,
+// instead of by the size of the reports. The grouping database is updated
+// once, with the unique findings of every check, when the data is applied. It
+// is not safe for concurrent use.
+type aggregator struct {
+	scanID       string
+	date         string
+	groupie      *groupie.Groupie
+	suppressions *Suppressions
+	policy       *Policy
+	inventory    Inventory
+
+	risk             vulcanreport.SeverityRank
+	assets           map[string]bool
+	checktypes       map[string]bool
+	perImpact        map[string]float64
+	perAsset         map[string]int
+	vulnerableAssets map[string]bool
+	topVulns         map[string]map[string]VulnerabilityCount
+	vulnerabilities  map[string]Vulnerability // Indexed by FindingKey.
+	suppressed       []SuppressedFinding
+	suppressedKeys   map[string]bool
+	overridden       []OverriddenFinding
+	// checks contains the unique findings of every check, indexed by
+	// checkKey, as they are stored in the grouping database.
+	checks map[string]*vulcanreport.Report
+	// metadata contains the metadata of the assets retrieved from the
+	// inventory, including the ones not found in it.
+	metadata MapInventory
+
+	// err is the first error returned by the inventory.
+	err error
+}
+
+func newAggregator(scanID, date string, g *groupie.Groupie, s *Suppressions, p *Policy, inv Inventory) *aggregator {
+	return &aggregator{
+		scanID:       scanID,
+		date:         date,
+		groupie:      g,
+		suppressions: s,
+		policy:       p,
+		inventory:    inv,
+		risk:         vulcanreport.SeverityNone,
+		assets:       make(map[string]bool),
+		// in the cases where a report does not contains any vulnerabilities,
+		// the pie chart library will complain about not being able to Generate
+		// a chart with only zero values. By putting a 0.01 we can work around
+		// this situation.
+		perImpact: map[string]float64{
+			"Info":     0.01,
+			"Low":      0.01,
+			"Medium":   0.01,
+			"High":     0.01,
+			"Critical": 0.01,
+		},
+		checktypes:       make(map[string]bool),
+		perAsset:         make(map[string]int),
+		vulnerableAssets: make(map[string]bool),
+		topVulns:         make(map[string]map[string]VulnerabilityCount),
+		vulnerabilities:  make(map[string]Vulnerability),
+		suppressedKeys:   make(map[string]bool),
+		checks:           make(map[string]*vulcanreport.Report),
+		metadata:         make(MapInventory),
+	}
+}
+
+// add updates the aggregated data with the given report of a check run
+// against an asset of the given type. The suppressed findings are set apart
+// and not included in the rest of the data, and the score of the rest is
+// overridden according to the policy and the metadata of the asset in the
+// inventory.
+func (a *aggregator) add(ctx context.Context, report *vulcanreport.Report, assetType string) {
+	// The grouping database only needs the fields identifying the check, so
+	// the rest of the report is not kept.
+	key := checkKey(report)
+	check, ok := a.checks[key]
+	if !ok {
+		check = &vulcanreport.Report{CheckData: vulcanreport.CheckData{
+			ChecktypeName: report.ChecktypeName,
+			Target:        report.Target,
+			Options:       report.Options,
+		}}
+		a.checks[key] = check
+	}
+	check.Status = report.Status
+
+	a.assets[report.Target] = true
+	a.checktypes[report.ChecktypeName] = true
+	md := a.assetMetadata(ctx, report.Target)
+
+	numVulnerabilities := 0
+	for _, vuln := range report.Vulnerabilities {
+		finding := Vulnerability{
+			Asset:         report.Target,
+			AssetType:     assetType,
+			CheckType:     report.ChecktypeName,
+			Vulnerability: vuln,
+			Options:       report.Options,
+		}
+		// A finding reported more than once, for instance by a check run
+		// twice against the same asset, is only counted, suppressed or
+		// overridden once.
+		key := FindingKey(finding)
+		if _, ok := a.vulnerabilities[key]; ok || a.suppressedKeys[key] {
+			continue
+		}
+		if rule, ok := a.suppressions.Match(finding, a.date); ok {
+			a.suppressedKeys[key] = true
+			a.suppressed = append(a.suppressed, SuppressedFinding{Vulnerability: summarize(finding), Rule: rule})
+			continue
+		}
+		if o, ok := a.policy.Apply(&finding, md); ok {
+			finding.Override = &o
+			a.overridden = append(a.overridden, OverriddenFinding{
+				Asset:     finding.Asset,
+				CheckType: finding.CheckType,
+				Summary:   finding.Vulnerability.Summary,
+				Override:  o,
+			})
+		}
+		a.vulnerabilities[key] = summarize(finding)
+		a.vulnerableAssets[report.Target] = true
+		check.Vulnerabilities = append(check.Vulnerabilities, groupieVulnerability(finding.Vulnerability))
+
+		vuln := finding.Vulnerability
+		severity := vuln.Severity()
+		impact := severityToString(severity)
+
+		// Risk is defined as the maximum severity found among all
+		// vulnerabilities.
+		if severity > a.risk {
+			a.risk = severity
+		}
+
+		a.perImpact[impact]++
+
+		//Ignore INFO
+		if severity != vulcanreport.SeverityNone {
+			numVulnerabilities++
+		}
+
+		// count the vulnerabilities grouped by summary and impact
+		if _, ok := a.topVulns[vuln.Summary]; !ok {
+			a.topVulns[vuln.Summary] = make(map[string]VulnerabilityCount)
+		}
+		count, ok := a.topVulns[vuln.Summary][impact]
+		if !ok {
+			count = VulnerabilityCount{Summary: vuln.Summary, Impact: impact}
+		}
+		count.Count++
+		a.topVulns[vuln.Summary][impact] = count
+	}
+	a.perAsset[report.Target] += numVulnerabilities
+}
+
+// assetMetadata returns the metadata of the asset in the inventory, which is
+// only requested the first time the asset is found. The assets not found in
+// the inventory have no metadata.
+func (a *aggregator) assetMetadata(ctx context.Context, asset string) AssetMetadata {
+	if md, ok := a.metadata[asset]; ok || a.inventory == nil || a.err != nil {
+		return md
+	}
+	metadata, err := a.inventory.Metadata(ctx, []string{asset})
+	if err != nil {
+		a.err = fmt.Errorf("error getting the metadata of %s: %w", asset, err)
+		return AssetMetadata{}
+	}
+	md := metadata[asset]
+	a.metadata[asset] = md
+	return md
+}
+
+// checkKey returns the key identifying a check in the grouping database: its
+// checktype, its target and its options.
+func checkKey(report *vulcanreport.Report) string {
+	return report.ChecktypeName + "|" + report.Target + "|" + report.Options
+}
+
+// apply sets the aggregated data in the given ReportData and updates the
+// grouping database with the unique findings of every check.
+func (a *aggregator) apply(rp *ReportData) error {
+	// The policy can not be applied without the metadata of the assets.
+	if a.err != nil {
+		return a.err
+	}
+	rp.Risk = a.risk
+	// An action is required if the risk is high or critical
+	rp.ActionRequired = a.risk >= vulcanreport.SeverityHigh
+	rp.Assets = sortedKeys(a.assets)
+	rp.CheckTypes = sortedKeys(a.checktypes)
+	rp.NumberOfVulnerableAssets = len(a.vulnerableAssets)
+	rp.VulnerabilitiesPerImpact = a.vulnerabilitiesPerImpact()
+	rp.VulnerabilitiesPerAsset = a.vulnerabilitiesPerAsset()
+	rp.TopVulnerabilities = a.topVulnerabilities()
+	rp.Vulnerabilities = a.allVulnerabilities()
+	rp.Suppressed = a.suppressed
+	if rp.Suppressed == nil {
+		rp.Suppressed = []SuppressedFinding{}
+	}
+	sortSuppressed(rp.Suppressed)
+	rp.ExpiredSuppressions = a.suppressions.Expired(a.date)
+	rp.Overrides = a.overridden
+	if rp.Overrides == nil {
+		rp.Overrides = []OverriddenFinding{}
+	}
+	sortOverridden(rp.Overrides)
+	rp.overrides = make(map[string]*ScoreOverride)
+	rp.grouped = make(map[string][]Vulnerability)
+	for _, v := range rp.Vulnerabilities {
+		if v.Override != nil {
+			rp.overrides[FindingKey(v)] = v.Override
+		}
+		key := groupedKey(v.Asset, v.CheckType, v.Vulnerability)
+		rp.grouped[key] = append(rp.grouped[key], v)
+	}
+
+	// Every check is stored once in the grouping database, even if it has
+	// been run more than once, so it does not grow with the duplicated
+	// reports.
+	keys := make([]string, 0, len(a.checks))
+	for key := range a.checks {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	reports := make([]vulcanreport.Report, 0, len(keys))
+	for _, key := range keys {
+		reports = append(reports, *a.checks[key])
+	}
+	return a.groupie.UpdateFromScan(a.scanID, a.date, reports)
+}
+
+func (a *aggregator) vulnerabilitiesPerImpact() []VulnerabilitiesPerImpact {
+	result := []VulnerabilitiesPerImpact{}
+	for _, impact := range []string{"Critical", "High", "Medium", "Low", "Info"} {
+		result = append(result, VulnerabilitiesPerImpact{Impact: impact, Vulnerabilities: a.perImpact[impact]})
+	}
+	return result
+}
+
+// returns the assets ordered by number of vulnerabilities
+func (a *aggregator) vulnerabilitiesPerAsset() []VulnerabilitiesPerAsset {
+	result := []VulnerabilitiesPerAsset{}
+	for target, numVulnerabilities := range a.perAsset {
+		result = append(result, VulnerabilitiesPerAsset{Asset: target, Vulnerabilities: numVulnerabilities})
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Vulnerabilities == result[j].Vulnerabilities {
+			return result[i].Asset > result[j].Asset
+		}
+		return result[i].Vulnerabilities > result[j].Vulnerabilities
+	})
+
+	return result
+}
+
+// returns the most frequent vulnerabilities with severity over Low and None
+func (a *aggregator) topVulnerabilities() []VulnerabilityCount {
+	result := []VulnerabilityCount{}
+	for _, vuln := range a.topVulns {
+		for _, vulnPerImpact := range vuln {
+			if vulnPerImpact.Impact != severityToString(vulcanreport.SeverityNone) && vulnPerImpact.Impact != severityToString(vulcanreport.SeverityLow) {
+				result = append(result, vulnPerImpact)
+			}
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if severityStringToInt(result[i].Impact) == severityStringToInt(result[j].Impact) {
+			if result[i].Count == result[j].Count {
+				return result[i].Summary < result[j].Summary
+			}
+
+			return result[i].Count > result[j].Count
+		}
+
+		return severityStringToInt(result[i].Impact) > severityStringToInt(result[j].Impact)
+	})
+
+	if len(result) > 10 {
+		return result[0:10]
+	}
+	return result
+}
+
+// returns all the vulnerabilities, sorted by severity and asset
+func (a *aggregator) allVulnerabilities() []Vulnerability {
+	// The vulnerabilities are first sorted by key, so the result does not
+	// depend on the order of the map.
+	keys := make([]string, 0, len(a.vulnerabilities))
+	for key := range a.vulnerabilities {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	result := make([]Vulnerability, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, a.vulnerabilities[key])
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Vulnerability.Severity() == result[j].Vulnerability.Severity() {
+			if result[i].Asset == result[j].Asset {
+				if result[i].CheckType == result[j].CheckType {
+					return result[i].CheckType < result[j].CheckType
+				}
+			}
+			return result[i].Asset < result[j].Asset
+		}
+		return result[i].Vulnerability.Severity() > result[j].Vulnerability.Severity()
+	})
+
+	return result
+}
+
+// summarize returns a copy of the finding keeping only the fields of the
+// vulnerability used by the report data: the ones identifying it and its
+// score. The details, resources and recommendations of the findings are shown
+// from the groups of the grouping database.
+func summarize(v Vulnerability) Vulnerability {
+	v.Vulnerability = vulcanreport.Vulnerability{
+		ID:                     v.Vulnerability.ID,
+		Summary:                v.Vulnerability.Summary,
+		Score:                  v.Vulnerability.Score,
+		AffectedResource:       v.Vulnerability.AffectedResource,
+		AffectedResourceString: v.Vulnerability.AffectedResourceString,
+		Fingerprint:            v.Vulnerability.Fingerprint,
+		CWEID:                  v.Vulnerability.CWEID,
+		Labels:                 v.Vulnerability.Labels,
+	}
+	return v
+}
+
+// groupieVulnerability returns a copy of the vulnerability without the fields
+// used neither by the grouping database nor by the groups shown in the full
+// report, like the attachments, which can be as large as the files found by
+// the check.
+func groupieVulnerability(v vulcanreport.Vulnerability) vulcanreport.Vulnerability {
+	v.Attachments = nil
+	if len(v.Vulnerabilities) > 0 {
+		vulns := make([]vulcanreport.Vulnerability, len(v.Vulnerabilities))
+		for i, nested := range v.Vulnerabilities {
+			vulns[i] = groupieVulnerability(nested)
+		}
+		v.Vulnerabilities = vulns
+	}
+	return v
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := []string{}
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
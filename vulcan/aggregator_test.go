@@ -0,0 +1,223 @@
+package vulcan
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/adevinta/vulcan-groupie/db"
+	"github.com/adevinta/vulcan-groupie/pkg/groupie"
+	vulcanreport "github.com/adevinta/vulcan-report"
+)
+
+func newTestReport(target, checktype string, vulns ...vulcanreport.Vulnerability) *vulcanreport.Report {
+	return &vulcanreport.Report{
+		CheckData: vulcanreport.CheckData{
+			CheckID:       checktype + "-" + target,
+			ChecktypeName: checktype,
+			Target:        target,
+			Status:        StatusFinished,
+		},
+		ResultData: vulcanreport.ResultData{Vulnerabilities: vulns},
+	}
+}
+
+func TestAggregator(t *testing.T) {
+	weakTLS := vulcanreport.Vulnerability{Summary: "Weak TLS", Score: 6.9, Description: "Long description", Recommendations: []string{"Upgrade"}}
+	expired := vulcanreport.Vulnerability{Summary: "Expired certificate", Score: 8.9, Fingerprint: "abc"}
+	info := vulcanreport.Vulnerability{Summary: "Open port", Score: 0}
+
+	tests := []struct {
+		name                 string
+		reports              []*vulcanreport.Report
+		wantRisk             vulcanreport.SeverityRank
+		wantActionRequired   bool
+		wantAssets           []string
+		wantVulnerableAssets int
+		wantPerImpact        map[string]float64
+		wantPerAsset         []VulnerabilitiesPerAsset
+		wantTop              []VulnerabilityCount
+		wantVulnerabilities  []string // Summaries of the vulnerabilities.
+	}{
+		{
+			name:                 "no vulnerabilities",
+			reports:              []*vulcanreport.Report{newTestReport("example.com", "vulcan-tls")},
+			wantRisk:             vulcanreport.SeverityNone,
+			wantAssets:           []string{"example.com"},
+			wantVulnerableAssets: 0,
+			wantPerImpact:        map[string]float64{"Critical": 0.01, "High": 0.01, "Medium": 0.01, "Low": 0.01, "Info": 0.01},
+			wantPerAsset:         []VulnerabilitiesPerAsset{{Asset: "example.com"}},
+			wantTop:              []VulnerabilityCount{},
+			wantVulnerabilities:  []string{},
+		},
+		{
+			name: "vulnerabilities",
+			reports: []*vulcanreport.Report{
+				newTestReport("example.com", "vulcan-tls", weakTLS, expired),
+				newTestReport("example.org", "vulcan-tls", weakTLS),
+				newTestReport("example.org", "vulcan-nmap", info),
+			},
+			wantRisk:             vulcanreport.SeverityHigh,
+			wantActionRequired:   true,
+			wantAssets:           []string{"example.com", "example.org"},
+			wantVulnerableAssets: 2,
+			wantPerImpact:        map[string]float64{"Critical": 0.01, "High": 1.01, "Medium": 2.01, "Low": 0.01, "Info": 1.01},
+			wantPerAsset:         []VulnerabilitiesPerAsset{{Asset: "example.com", Vulnerabilities: 2}, {Asset: "example.org", Vulnerabilities: 1}},
+			wantTop: []VulnerabilityCount{
+				{Summary: "Expired certificate", Impact: "High", Count: 1},
+				{Summary: "Weak TLS", Impact: "Medium", Count: 2},
+			},
+			wantVulnerabilities: []string{"Expired certificate", "Weak TLS", "Weak TLS", "Open port"},
+		},
+		{
+			name: "duplicated findings",
+			reports: []*vulcanreport.Report{
+				newTestReport("example.com", "vulcan-tls", weakTLS, weakTLS),
+				newTestReport("example.com", "vulcan-tls", weakTLS),
+			},
+			wantRisk:             vulcanreport.SeverityMedium,
+			wantAssets:           []string{"example.com"},
+			wantVulnerableAssets: 1,
+			wantPerImpact:        map[string]float64{"Critical": 0.01, "High": 0.01, "Medium": 1.01, "Low": 0.01, "Info": 0.01},
+			wantPerAsset:         []VulnerabilitiesPerAsset{{Asset: "example.com", Vulnerabilities: 1}},
+			wantTop:              []VulnerabilityCount{{Summary: "Weak TLS", Impact: "Medium", Count: 1}},
+			wantVulnerabilities:  []string{"Weak TLS"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newAggregator("scan", "2022-10-12", groupie.New(db.NewMemDB()), nil, nil, nil)
+			for _, r := range tt.reports {
+				a.add(context.Background(), r, InferAssetType(r.Target))
+			}
+			rd := &ReportData{}
+			if err := a.apply(rd); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if rd.Risk != tt.wantRisk {
+				t.Errorf("unexpected risk: got %v, want %v", rd.Risk, tt.wantRisk)
+			}
+			if rd.ActionRequired != tt.wantActionRequired {
+				t.Errorf("unexpected action required: got %v, want %v", rd.ActionRequired, tt.wantActionRequired)
+			}
+			if !reflect.DeepEqual(rd.Assets, tt.wantAssets) {
+				t.Errorf("unexpected assets: got %v, want %v", rd.Assets, tt.wantAssets)
+			}
+			if rd.NumberOfVulnerableAssets != tt.wantVulnerableAssets {
+				t.Errorf("unexpected vulnerable assets: got %d, want %d", rd.NumberOfVulnerableAssets, tt.wantVulnerableAssets)
+			}
+			perImpact := make(map[string]float64)
+			for _, v := range rd.VulnerabilitiesPerImpact {
+				perImpact[v.Impact] = v.Vulnerabilities
+			}
+			if !reflect.DeepEqual(perImpact, tt.wantPerImpact) {
+				t.Errorf("unexpected vulnerabilities per impact: got %v, want %v", perImpact, tt.wantPerImpact)
+			}
+			if !reflect.DeepEqual(rd.VulnerabilitiesPerAsset, tt.wantPerAsset) {
+				t.Errorf("unexpected vulnerabilities per asset: got %v, want %v", rd.VulnerabilitiesPerAsset, tt.wantPerAsset)
+			}
+			if !reflect.DeepEqual(rd.TopVulnerabilities, tt.wantTop) {
+				t.Errorf("unexpected top vulnerabilities: got %v, want %v", rd.TopVulnerabilities, tt.wantTop)
+			}
+			summaries := []string{}
+			for _, v := range rd.Vulnerabilities {
+				summaries = append(summaries, v.Vulnerability.Summary)
+				// Only the fields needed by the report data are kept.
+				if v.Vulnerability.Description != "" || v.Vulnerability.Recommendations != nil {
+					t.Errorf("unexpected details of %s: %+v", v.Vulnerability.Summary, v.Vulnerability)
+				}
+			}
+			if !reflect.DeepEqual(summaries, tt.wantVulnerabilities) {
+				t.Errorf("unexpected vulnerabilities: got %v, want %v", summaries, tt.wantVulnerabilities)
+			}
+		})
+	}
+}
+
+func TestAggregatorTopVulnerabilities(t *testing.T) {
+	a := newAggregator("scan", "2022-10-12", groupie.New(db.NewMemDB()), nil, nil, nil)
+	for i := 0; i < 12; i++ {
+		var vulns []vulcanreport.Vulnerability
+		// The vulnerability i is found in i+1 assets.
+		for j := 0; j <= i; j++ {
+			vulns = append(vulns, vulcanreport.Vulnerability{Summary: fmt.Sprintf("vuln %02d", j), Score: 6.9})
+		}
+		a.add(context.Background(), newTestReport(fmt.Sprintf("host%02d.example.com", i), "vulcan-check", vulns...), AssetTypeHostname)
+	}
+
+	top := a.topVulnerabilities()
+	if len(top) != 10 {
+		t.Fatalf("unexpected number of top vulnerabilities: got %d, want 10", len(top))
+	}
+	for i, v := range top {
+		want := VulnerabilityCount{Summary: fmt.Sprintf("vuln %02d", i), Impact: "Medium", Count: 12 - i}
+		if v != want {
+			t.Errorf("unexpected top vulnerability %d: got %+v, want %+v", i, v, want)
+		}
+	}
+}
+
+func TestAggregatorGroupieDB(t *testing.T) {
+	weakTLS := vulcanreport.Vulnerability{
+		Summary:     "Weak TLS",
+		Score:       6.9,
+		Details:     "TLSv1.0 enabled",
+		Attachments: []vulcanreport.Attachment{{Name: "handshake.pcap", Data: make([]byte, 1024)}},
+	}
+	expired := vulcanreport.Vulnerability{Summary: "Expired certificate", Score: 8.9, Fingerprint: "abc"}
+
+	tests := []struct {
+		name    string
+		reports int
+	}{
+		{name: "single report", reports: 1},
+		{name: "duplicated reports", reports: 50},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := db.NewMemDB()
+			a := newAggregator("scan", "2022-10-12", groupie.New(m), nil, nil, nil)
+			for i := 0; i < tt.reports; i++ {
+				a.add(context.Background(), newTestReport("example.com", "vulcan-tls", weakTLS, expired, weakTLS), AssetTypeHostname)
+			}
+			a.add(context.Background(), newTestReport("example.org", "vulcan-tls"), AssetTypeHostname)
+			if err := a.apply(&ReportData{}); err != nil {
+				t.Fatal(err)
+			}
+
+			// Every check is stored once, with its unique findings and
+			// without their attachments, however many reports it has.
+			if len(m.Historic) != 2 {
+				t.Fatalf("unexpected number of checks: got %d, want 2", len(m.Historic))
+			}
+			for _, entries := range m.Historic {
+				if len(entries) != 1 {
+					t.Fatalf("unexpected number of entries: got %d, want 1", len(entries))
+				}
+				r := entries[0].Report
+				if r.Target == "example.org" {
+					if len(r.Vulnerabilities) != 0 {
+						t.Errorf("unexpected vulnerabilities of %s: %+v", r.Target, r.Vulnerabilities)
+					}
+					continue
+				}
+				if len(r.Vulnerabilities) != 2 {
+					t.Fatalf("unexpected vulnerabilities of %s: got %d, want 2", r.Target, len(r.Vulnerabilities))
+				}
+				for _, v := range r.Vulnerabilities {
+					if v.Attachments != nil {
+						t.Errorf("unexpected attachments of %s", v.Summary)
+					}
+				}
+				if r.Vulnerabilities[0].Details != weakTLS.Details {
+					t.Errorf("unexpected details: got %q, want %q", r.Vulnerabilities[0].Details, weakTLS.Details)
+				}
+			}
+			if len(a.checks) != 2 || len(a.vulnerabilities) != 2 {
+				t.Errorf("unexpected state: %d checks, %d vulnerabilities", len(a.checks), len(a.vulnerabilities))
+			}
+		})
+	}
+}
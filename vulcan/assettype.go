@@ -0,0 +1,111 @@
+package vulcan
+
+import (
+	"net"
+	"regexp"
+	"strings"
+
+	"github.com/adevinta/security-overview/vulcan/persistence"
+)
+
+// The types of the assets scanned by Vulcan.
+const (
+	AssetTypeHostname      = "Hostname"
+	AssetTypeDomainName    = "DomainName"
+	AssetTypeIP            = "IP"
+	AssetTypeIPRange       = "IPRange"
+	AssetTypeWebAddress    = "WebAddress"
+	AssetTypeDockerImage   = "DockerImage"
+	AssetTypeAWSAccount    = "AWSAccount"
+	AssetTypeGitRepository = "GitRepository"
+)
+
+var (
+	awsAccountRegexp  = regexp.MustCompile(`^(arn:aws:iam::)?[0-9]{12}(:root)?$`)
+	hostnameRegexp    = regexp.MustCompile(`^([a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?\.)+[a-zA-Z]{2,63}\.?$`)
+	dockerImageRegexp = regexp.MustCompile(`^[a-z0-9]+([._:/@-][a-zA-Z0-9_.-]+)+$`)
+)
+
+// InferAssetType returns the type of an asset deduced from the format of its
+// identifier, or an empty string if it can not be deduced. The domains can not
+// be told apart from the hostnames by their format, so they are reported as
+// hostnames.
+func InferAssetType(target string) string {
+	switch {
+	case net.ParseIP(target) != nil:
+		return AssetTypeIP
+	case isIPRange(target):
+		return AssetTypeIPRange
+	case awsAccountRegexp.MatchString(target):
+		return AssetTypeAWSAccount
+	case strings.HasPrefix(target, "git@") || strings.HasSuffix(target, ".git"):
+		return AssetTypeGitRepository
+	case strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://"):
+		return AssetTypeWebAddress
+	case hostnameRegexp.MatchString(target):
+		return AssetTypeHostname
+	case dockerImageRegexp.MatchString(target):
+		return AssetTypeDockerImage
+	default:
+		return ""
+	}
+}
+
+func isIPRange(target string) bool {
+	_, _, err := net.ParseCIDR(target)
+	return err == nil
+}
+
+// checkAssetType returns the type of the asset of a check, as stored in
+// vulcan-persistence or, if it is not, inferred from the target.
+func checkAssetType(check persistence.Check) string {
+	if check.AssetType != "" {
+		return check.AssetType
+	}
+	return InferAssetType(check.Target)
+}
+
+// AssetTypeSummary contains the assets of a type, the vulnerabilities found in
+// them and the checktypes run against them.
+type AssetTypeSummary struct {
+	AssetGroup
+	CheckTypes []string `json:"checktypes"`
+}
+
+// AssetTypeSummaries groups the assets of the report data by type. The assets
+// whose type is unknown are grouped last.
+func (rp *ReportData) AssetTypeSummaries() []AssetTypeSummary {
+	groups, _ := groupAssets(rp.Assets, rp.issuesPerAsset(), func(asset string) []string {
+		return []string{rp.AssetTypes[asset]}
+	})
+
+	checktypes := make(map[string]map[string]bool)
+	for _, c := range rp.Coverage {
+		t := rp.AssetTypes[c.Asset]
+		if t == "" {
+			t = unknownValue
+		}
+		if checktypes[t] == nil {
+			checktypes[t] = make(map[string]bool)
+		}
+		for _, check := range c.Checks {
+			checktypes[t][check.CheckType] = true
+		}
+	}
+
+	result := []AssetTypeSummary{}
+	for _, g := range groups {
+		result = append(result, AssetTypeSummary{AssetGroup: g, CheckTypes: sortedKeys(checktypes[g.Value])})
+	}
+	return result
+}
+
+// AssetTypeBreakdown groups the assets of the report data by type, like the
+// breakdowns by the fields of their metadata.
+func (rp *ReportData) AssetTypeBreakdown() AssetBreakdown {
+	b := AssetBreakdown{Field: "Asset Type"}
+	for _, s := range rp.AssetTypeSummaries() {
+		b.Groups = append(b.Groups, s.AssetGroup)
+	}
+	return b
+}
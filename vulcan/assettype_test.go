@@ -0,0 +1,113 @@
+package vulcan
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/adevinta/security-overview/vulcan/persistence"
+	vulcanreport "github.com/adevinta/vulcan-report"
+)
+
+func TestInferAssetType(t *testing.T) {
+	tests := []struct {
+		target string
+		want   string
+	}{
+		{target: "192.0.2.1", want: AssetTypeIP},
+		{target: "2001:db8::1", want: AssetTypeIP},
+		{target: "192.0.2.0/24", want: AssetTypeIPRange},
+		{target: "123456789012", want: AssetTypeAWSAccount},
+		{target: "arn:aws:iam::123456789012:root", want: AssetTypeAWSAccount},
+		{target: "git@github.com:adevinta/vulcan-report.git", want: AssetTypeGitRepository},
+		{target: "https://github.com/adevinta/vulcan-report.git", want: AssetTypeGitRepository},
+		{target: "https://www.example.com/login", want: AssetTypeWebAddress},
+		{target: "www.example.com", want: AssetTypeHostname},
+		{target: "example.com.", want: AssetTypeHostname},
+		{target: "registry.example.com/app:1.0", want: AssetTypeDockerImage},
+		{target: "alpine:3.16", want: AssetTypeDockerImage},
+		{target: "localhost", want: ""},
+		{target: "", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			if got := InferAssetType(tt.target); got != tt.want {
+				t.Errorf("unexpected type: got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckAssetType(t *testing.T) {
+	// The type stored in vulcan-persistence takes precedence.
+	if got := checkAssetType(persistence.Check{Target: "example.com", AssetType: AssetTypeDomainName}); got != AssetTypeDomainName {
+		t.Errorf("unexpected type: got %q, want %q", got, AssetTypeDomainName)
+	}
+	if got := checkAssetType(persistence.Check{Target: "example.com"}); got != AssetTypeHostname {
+		t.Errorf("unexpected type: got %q, want %q", got, AssetTypeHostname)
+	}
+}
+
+func TestEnrichAssetsType(t *testing.T) {
+	rd := &ReportData{
+		Assets:     []string{"example.com", "www.example.com"},
+		AssetTypes: map[string]string{"example.com": AssetTypeHostname, "www.example.com": AssetTypeHostname},
+		Vulnerabilities: []Vulnerability{
+			{Asset: "example.com", AssetType: AssetTypeHostname, Vulnerability: vulcanreport.Vulnerability{Summary: "Weak TLS", Score: 6.9}},
+		},
+		Suppressed: []SuppressedFinding{
+			{Vulnerability: Vulnerability{Asset: "example.com", AssetType: AssetTypeHostname, Vulnerability: vulcanreport.Vulnerability{Summary: "Open port"}}},
+		},
+	}
+	inv := MapInventory{"example.com": {Asset: "example.com", Type: AssetTypeDomainName}}
+	if err := rd.EnrichAssets(context.Background(), inv); err != nil {
+		t.Fatal(err)
+	}
+
+	// The type kept in the inventory overrides the one of the scan in every
+	// part of the report data.
+	want := map[string]string{"example.com": AssetTypeDomainName, "www.example.com": AssetTypeHostname}
+	if !reflect.DeepEqual(rd.AssetTypes, want) {
+		t.Errorf("unexpected asset types: got %v, want %v", rd.AssetTypes, want)
+	}
+	if got := rd.AssetsMetadata["www.example.com"].Type; got != AssetTypeHostname {
+		t.Errorf("unexpected metadata type: got %q, want %q", got, AssetTypeHostname)
+	}
+	if got := rd.Vulnerabilities[0].AssetType; got != AssetTypeDomainName {
+		t.Errorf("unexpected type of the vulnerability: got %q, want %q", got, AssetTypeDomainName)
+	}
+	if got := rd.Suppressed[0].AssetType; got != AssetTypeDomainName {
+		t.Errorf("unexpected type of the suppressed finding: got %q, want %q", got, AssetTypeDomainName)
+	}
+}
+
+func TestAssetTypeSummaries(t *testing.T) {
+	rd := &ReportData{
+		Assets:     []string{"example.com", "192.0.2.1", "unknown"},
+		AssetTypes: map[string]string{"example.com": AssetTypeHostname, "192.0.2.1": AssetTypeIP},
+		Vulnerabilities: []Vulnerability{
+			{Asset: "example.com", Vulnerability: vulcanreport.Vulnerability{Summary: "Weak TLS", Score: 6.9}},
+		},
+		Coverage: []AssetCoverage{
+			{Asset: "example.com", Checks: []CheckCoverage{{CheckType: "vulcan-tls"}, {CheckType: "vulcan-nmap"}}},
+			{Asset: "192.0.2.1", Checks: []CheckCoverage{{CheckType: "vulcan-nmap"}}},
+			{Asset: "unknown", Checks: []CheckCoverage{{CheckType: "vulcan-exposed-http"}}},
+		},
+	}
+
+	var got []string
+	for _, s := range rd.AssetTypeSummaries() {
+		got = append(got, s.Value)
+		if s.Value == AssetTypeHostname {
+			if want := []string{"vulcan-nmap", "vulcan-tls"}; !reflect.DeepEqual(s.CheckTypes, want) {
+				t.Errorf("unexpected checktypes: got %v, want %v", s.CheckTypes, want)
+			}
+			if s.VulnerableAssets != 1 {
+				t.Errorf("unexpected vulnerable assets: got %d, want 1", s.VulnerableAssets)
+			}
+		}
+	}
+	if want := []string{AssetTypeHostname, AssetTypeIP, unknownValue}; !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected types: got %v, want %v", got, want)
+	}
+}
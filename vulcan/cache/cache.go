@@ -0,0 +1,229 @@
+// Package cache implements a persistent on-disk cache of the responses of the
+// Vulcan APIs, so regenerating the report of a scan only requests the data
+// that is not already cached.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	objectsDir = "objects"
+	keysDir    = "keys"
+)
+
+// Cache is a content-addressed cache stored in a local directory:
+//
+//	<dir>/
+//	'
+//	'--keys/
+//	'     '
+//	'     '--<hex(sha256(key))>     (entry pointing to an object)
+//	'
+//	'--objects/
+//	      '
+//	      '--<hex(sha256(data))>    (cached data)
+//
+// Entries older than the TTL are ignored. When the size of the objects exceeds
+// the maximum size, the oldest entries are removed. Zero TTL and maximum size
+// mean no limit. It is safe for concurrent use.
+type Cache struct {
+	dir     string
+	ttl     time.Duration
+	maxSize int64
+
+	// mu serializes the writes and the pruning, so an object is never
+	// removed before the entry pointing to it is written.
+	mu sync.Mutex
+	// size is the size of the objects, updated by prune and Put.
+	size int64
+}
+
+// entry is the content of the files stored in the keys folder.
+type entry struct {
+	Key    string              `json:"key"`
+	Object string              `json:"object"`
+	Header map[string][]string `json:"header,omitempty"`
+}
+
+// New returns a cache stored in the given directory, creating it if needed,
+// and removes the expired entries and the ones exceeding the maximum size.
+func New(dir string, ttl time.Duration, maxSize int64) (*Cache, error) {
+	c := &Cache{dir: dir, ttl: ttl, maxSize: maxSize}
+	for _, d := range []string{objectsDir, keysDir} {
+		if err := os.MkdirAll(filepath.Join(dir, d), 0700); err != nil {
+			return nil, err
+		}
+	}
+	if err := c.prune(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Get returns the data and the metadata stored for the given key. The last
+// return value is false if the key is not cached or it has expired.
+func (c *Cache) Get(key string) ([]byte, map[string][]string, bool) {
+	path := c.keyPath(key)
+	info, err := os.Stat(path)
+	if err != nil || c.expired(info.ModTime()) {
+		return nil, nil, false
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, false
+	}
+	var e entry
+	if err := json.Unmarshal(content, &e); err != nil || e.Key != key {
+		return nil, nil, false
+	}
+	data, err := os.ReadFile(filepath.Join(c.dir, objectsDir, e.Object))
+	if err != nil {
+		return nil, nil, false
+	}
+	return data, e.Header, true
+}
+
+// Put stores the data and the metadata for the given key. Identical data
+// stored for different keys is stored only once. When the size of the objects
+// exceeds the maximum size the cache is pruned.
+func (c *Cache) Put(key string, data []byte, header map[string][]string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	object := fmt.Sprintf("%x", sha256.Sum256(data))
+	objectPath := filepath.Join(c.dir, objectsDir, object)
+	if _, err := os.Stat(objectPath); errors.Is(err, os.ErrNotExist) {
+		if err := writeFile(objectPath, data); err != nil {
+			return err
+		}
+		c.size += int64(len(data))
+	}
+	content, err := json.Marshal(entry{Key: key, Object: object, Header: header})
+	if err != nil {
+		return err
+	}
+	if err := writeFile(c.keyPath(key), content); err != nil {
+		return err
+	}
+	if c.maxSize > 0 && c.size > c.maxSize {
+		return c.prune()
+	}
+	return nil
+}
+
+func (c *Cache) keyPath(key string) string {
+	return filepath.Join(c.dir, keysDir, fmt.Sprintf("%x", sha256.Sum256([]byte(key))))
+}
+
+func (c *Cache) expired(t time.Time) bool {
+	return c.ttl > 0 && time.Since(t) > c.ttl
+}
+
+// prune removes the expired entries and, starting from the oldest ones, the
+// entries needed to keep the size of the cache under the maximum size. Then
+// it removes the objects not referenced by any entry. It must be called with
+// the lock held, except from New.
+func (c *Cache) prune() error {
+	files, err := os.ReadDir(filepath.Join(c.dir, keysDir))
+	if err != nil {
+		return err
+	}
+
+	type keyFile struct {
+		path    string
+		object  string
+		modTime time.Time
+	}
+	var keys []keyFile
+	for _, f := range files {
+		path := filepath.Join(c.dir, keysDir, f.Name())
+		info, err := f.Info()
+		if err != nil {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var e entry
+		if err := json.Unmarshal(content, &e); err != nil || c.expired(info.ModTime()) {
+			if err := os.Remove(path); err != nil {
+				return err
+			}
+			continue
+		}
+		keys = append(keys, keyFile{path: path, object: e.Object, modTime: info.ModTime()})
+	}
+
+	objectSizes := make(map[string]int64)
+	objects, err := os.ReadDir(filepath.Join(c.dir, objectsDir))
+	if err != nil {
+		return err
+	}
+	for _, o := range objects {
+		info, err := o.Info()
+		if err != nil {
+			return err
+		}
+		objectSizes[o.Name()] = info.Size()
+	}
+
+	// Newest entries first, so the oldest ones are the first to exceed the
+	// maximum size.
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].modTime.After(keys[j].modTime)
+	})
+	var size int64
+	referenced := make(map[string]bool)
+	for _, k := range keys {
+		if !referenced[k.object] {
+			if c.maxSize > 0 && size+objectSizes[k.object] > c.maxSize {
+				if err := os.Remove(k.path); err != nil {
+					return err
+				}
+				continue
+			}
+			size += objectSizes[k.object]
+		}
+		referenced[k.object] = true
+	}
+
+	for object := range objectSizes {
+		if referenced[object] {
+			continue
+		}
+		if err := os.Remove(filepath.Join(c.dir, objectsDir, object)); err != nil {
+			return err
+		}
+	}
+	c.size = size
+	return nil
+}
+
+// writeFile writes the file atomically, so concurrent readers never see a
+// partially written file.
+func writeFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
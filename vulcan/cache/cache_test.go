@@ -0,0 +1,112 @@
+package cache
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCacheGetPut(t *testing.T) {
+	c, err := New(t.TempDir(), 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := map[string][]string{"Content-Type": {"application/json"}}
+	if err := c.Put("a", []byte("data"), header); err != nil {
+		t.Fatal(err)
+	}
+	// Identical data is stored once.
+	if err := c.Put("b", []byte("data"), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		key        string
+		wantData   []byte
+		wantHeader map[string][]string
+		wantOK     bool
+	}{
+		{key: "a", wantData: []byte("data"), wantHeader: header, wantOK: true},
+		{key: "b", wantData: []byte("data"), wantOK: true},
+		{key: "c", wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			data, header, ok := c.Get(tt.key)
+			if ok != tt.wantOK {
+				t.Fatalf("unexpected result: got %v, want %v", ok, tt.wantOK)
+			}
+			if !bytes.Equal(data, tt.wantData) {
+				t.Errorf("unexpected data: got %q, want %q", data, tt.wantData)
+			}
+			if !reflect.DeepEqual(header, tt.wantHeader) {
+				t.Errorf("unexpected header: got %v, want %v", header, tt.wantHeader)
+			}
+		})
+	}
+	if n := countFiles(t, filepath.Join(c.dir, objectsDir)); n != 1 {
+		t.Errorf("unexpected number of objects: got %d, want 1", n)
+	}
+}
+
+func TestCacheLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		ttl     time.Duration
+		maxSize int64
+		// age is how old the first entry is.
+		age      time.Duration
+		wantKeys []string
+	}{
+		{name: "no limits", age: time.Hour, wantKeys: []string{"first", "second", "third"}},
+		{name: "expired", ttl: time.Minute, age: time.Hour, wantKeys: []string{"second", "third"}},
+		{name: "not expired", ttl: time.Minute, wantKeys: []string{"first", "second", "third"}},
+		// Every entry is 4 bytes long, so only the newest two fit.
+		{name: "max size", maxSize: 8, age: time.Hour, wantKeys: []string{"second", "third"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := New(t.TempDir(), tt.ttl, tt.maxSize)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := c.Put("first", []byte("aaaa"), nil); err != nil {
+				t.Fatal(err)
+			}
+			old := time.Now().Add(-tt.age)
+			if err := os.Chtimes(c.keyPath("first"), old, old); err != nil {
+				t.Fatal(err)
+			}
+			for _, key := range []string{"second", "third"} {
+				if err := c.Put(key, []byte(key[:4]), nil); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var keys []string
+			for _, key := range []string{"first", "second", "third"} {
+				if _, _, ok := c.Get(key); ok {
+					keys = append(keys, key)
+				}
+			}
+			if !reflect.DeepEqual(keys, tt.wantKeys) {
+				t.Errorf("unexpected keys: got %v, want %v", keys, tt.wantKeys)
+			}
+			if tt.maxSize > 0 && c.size > tt.maxSize {
+				t.Errorf("size over the maximum: %d", c.size)
+			}
+		})
+	}
+}
+
+func countFiles(t *testing.T, dir string) int {
+	t.Helper()
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(files)
+}
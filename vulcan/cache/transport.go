@@ -0,0 +1,89 @@
+package cache
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+)
+
+// ErrOffline is returned when a response is not cached and the cache is in
+// offline mode.
+var ErrOffline = errors.New("response not cached in offline mode")
+
+// Mode defines how a Transport uses the cache.
+type Mode int
+
+const (
+	// ModeReadThrough serves the cached responses and caches the ones not
+	// cached yet.
+	ModeReadThrough Mode = iota
+	// ModeWriteOnly always sends the requests and caches the responses, so
+	// they are available in offline mode.
+	ModeWriteOnly
+	// ModeOffline only serves cached responses and fails with ErrOffline
+	// instead of sending the requests.
+	ModeOffline
+)
+
+// cachedHeaders are the headers of a response stored in the cache.
+var cachedHeaders = []string{"Content-Type", "Link"}
+
+// Transport is an http.RoundTripper that caches the successful responses to
+// GET requests, keyed by the URL of the request.
+type Transport struct {
+	Base  http.RoundTripper
+	Cache *Cache
+	Mode  Mode
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return t.Base.RoundTrip(req)
+	}
+
+	key := req.URL.String()
+	if t.Mode != ModeWriteOnly {
+		if data, header, ok := t.Cache.Get(key); ok {
+			return &http.Response{
+				Status:        "200 OK",
+				StatusCode:    http.StatusOK,
+				Proto:         "HTTP/1.1",
+				ProtoMajor:    1,
+				ProtoMinor:    1,
+				Header:        http.Header(header),
+				Body:          io.NopCloser(bytes.NewReader(data)),
+				ContentLength: int64(len(data)),
+				Request:       req,
+			}, nil
+		}
+	}
+	if t.Mode == ModeOffline {
+		return nil, fmt.Errorf("%w: %s", ErrOffline, key)
+	}
+
+	resp, err := t.Base.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	header := make(map[string][]string)
+	for _, h := range cachedHeaders {
+		if v := resp.Header.Values(h); len(v) > 0 {
+			header[h] = v
+		}
+	}
+	// The response was read successfully, so failing to cache it does not
+	// fail the request.
+	if err := t.Cache.Put(key, data, header); err != nil {
+		log.Printf("error caching %s: %v", key, err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+	return resp, nil
+}
@@ -0,0 +1,117 @@
+package cache
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTransport(t *testing.T) {
+	tests := []struct {
+		name string
+		mode Mode
+		// cached is the body cached before the request, if any.
+		cached       string
+		wantBody     string
+		wantRequests int
+		wantCached   string
+		wantErr      error
+	}{
+		{name: "read-through miss", mode: ModeReadThrough, wantBody: "server", wantRequests: 1, wantCached: "server"},
+		{name: "read-through hit", mode: ModeReadThrough, cached: "cached", wantBody: "cached", wantRequests: 0, wantCached: "cached"},
+		{name: "write-only", mode: ModeWriteOnly, cached: "cached", wantBody: "server", wantRequests: 1, wantCached: "server"},
+		{name: "offline hit", mode: ModeOffline, cached: "cached", wantBody: "cached", wantRequests: 0, wantCached: "cached"},
+		{name: "offline miss", mode: ModeOffline, wantRequests: 0, wantErr: ErrOffline},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				w.Header().Set("Link", `<next>; rel="next"`)
+				w.Write([]byte("server"))
+			}))
+			defer srv.Close()
+
+			c, err := New(t.TempDir(), 0, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			u := srv.URL + "/v1/scans/s"
+			if tt.cached != "" {
+				if err := c.Put(u, []byte(tt.cached), nil); err != nil {
+					t.Fatal(err)
+				}
+			}
+			client := &http.Client{Transport: &Transport{Base: http.DefaultTransport, Cache: c, Mode: tt.mode}}
+
+			resp, err := client.Get(u)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("unexpected error: got %v, want %v", err, tt.wantErr)
+			}
+			if err == nil {
+				body, err := io.ReadAll(resp.Body)
+				resp.Body.Close()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(body) != tt.wantBody {
+					t.Errorf("unexpected body: got %q, want %q", body, tt.wantBody)
+				}
+			}
+			if requests != tt.wantRequests {
+				t.Errorf("unexpected number of requests: got %d, want %d", requests, tt.wantRequests)
+			}
+			data, header, _ := c.Get(u)
+			if string(data) != tt.wantCached {
+				t.Errorf("unexpected cached data: got %q, want %q", data, tt.wantCached)
+			}
+			if tt.wantCached == "server" && http.Header(header).Get("Link") == "" {
+				t.Error("Link header not cached")
+			}
+		})
+	}
+}
+
+func TestTransportNotCached(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		status int
+	}{
+		{name: "post", method: http.MethodPost, status: http.StatusOK},
+		{name: "not found", method: http.MethodGet, status: http.StatusNotFound},
+		{name: "server error", method: http.MethodGet, status: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			c, err := New(t.TempDir(), 0, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			client := &http.Client{Transport: &Transport{Base: http.DefaultTransport, Cache: c, Mode: ModeReadThrough}}
+			req, err := http.NewRequest(tt.method, srv.URL, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.status {
+				t.Errorf("unexpected status code: got %d, want %d", resp.StatusCode, tt.status)
+			}
+			if _, _, ok := c.Get(srv.URL); ok {
+				t.Error("unexpected cached response")
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package client
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+)
+
+// Auth contains the credentials used to authenticate the requests sent to an
+// API. When both a token and a username are defined, the token is used.
+type Auth struct {
+	// Token is sent as a bearer token.
+	Token string
+	// Username and Password are sent using basic authentication.
+	Username string
+	Password string
+	// ClientCert and ClientKey are the paths to the PEM encoded certificate
+	// and key used for mutual TLS authentication.
+	ClientCert string
+	ClientKey  string
+}
+
+// NewAuthClient returns an HTTP client that uses the given transport and
+// authenticates every request with the given credentials. When a client
+// certificate is defined, the connections are not shared with other clients.
+func NewAuthClient(transport *http.Transport, auth Auth) (*http.Client, error) {
+	if auth.ClientCert != "" || auth.ClientKey != "" {
+		cert, err := tls.LoadX509KeyPair(auth.ClientCert, auth.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("loading client certificate: %w", err)
+		}
+		transport = transport.Clone()
+		if transport.TLSClientConfig == nil {
+			transport.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+		transport.TLSClientConfig.Certificates = []tls.Certificate{cert}
+	}
+	c := New(transport)
+	c.Transport = &authTransport{base: c.Transport, auth: auth}
+	return c, nil
+}
+
+// authTransport is an http.RoundTripper that adds the credentials to the
+// requests.
+type authTransport struct {
+	base http.RoundTripper
+	auth Auth
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	switch {
+	case t.auth.Token != "":
+		req = req.Clone(req.Context())
+		req.Header.Set("Authorization", "Bearer "+t.auth.Token)
+	case t.auth.Username != "":
+		req = req.Clone(req.Context())
+		req.SetBasicAuth(t.auth.Username, t.auth.Password)
+	}
+	return t.base.RoundTrip(req)
+}
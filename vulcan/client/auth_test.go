@@ -0,0 +1,146 @@
+package client
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeTestCert writes a self-signed client certificate with the given common
+// name, and its key, as PEM files in the given directory.
+func writeTestCert(t *testing.T, dir, cn string) (certFile, keyFile string, cert *x509.Certificate) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err = x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile = filepath.Join(dir, cn+".crt")
+	keyFile = filepath.Join(dir, cn+".key")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile, cert
+}
+
+func TestNewAuthClient(t *testing.T) {
+	tests := []struct {
+		name     string
+		auth     Auth
+		wantAuth string
+	}{
+		{name: "no credentials", auth: Auth{}, wantAuth: ""},
+		{name: "bearer", auth: Auth{Token: "secret"}, wantAuth: "Bearer secret"},
+		{name: "basic", auth: Auth{Username: "user", Password: "pass"}, wantAuth: "Basic dXNlcjpwYXNz"},
+		{name: "token over basic", auth: Auth{Token: "secret", Username: "user", Password: "pass"}, wantAuth: "Bearer secret"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got http.Header
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.Header
+			}))
+			defer srv.Close()
+
+			c, err := NewAuthClient(http.DefaultTransport.(*http.Transport).Clone(), tt.auth)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := c.Get(srv.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			if auth := got.Get("Authorization"); auth != tt.wantAuth {
+				t.Errorf("unexpected Authorization header: got %q, want %q", auth, tt.wantAuth)
+			}
+			if ua := got.Get("User-Agent"); ua != UserAgent() {
+				t.Errorf("unexpected User-Agent header: got %q, want %q", ua, UserAgent())
+			}
+		})
+	}
+}
+
+func TestNewAuthClientTLS(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile, cert := writeTestCert(t, dir, "client")
+
+	var peer string
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		peer = r.TLS.PeerCertificates[0].Subject.CommonName
+	}))
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(cert)
+	srv.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	srv.StartTLS()
+	defer srv.Close()
+
+	// The transport shared by the clients trusts the server.
+	roots := x509.NewCertPool()
+	roots.AddCert(srv.Certificate())
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12}
+
+	c, err := NewAuthClient(transport, Auth{ClientCert: certFile, ClientKey: keyFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := c.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if peer != "client" {
+		t.Errorf("unexpected client certificate: got %q, want %q", peer, "client")
+	}
+
+	// The certificate is not added to the shared transport, so the other
+	// clients are rejected.
+	if len(transport.TLSClientConfig.Certificates) != 0 {
+		t.Error("client certificate added to the shared transport")
+	}
+	if resp, err := New(transport).Get(srv.URL); err == nil {
+		resp.Body.Close()
+		t.Error("expected error without client certificate")
+	}
+
+	if _, err := NewAuthClient(transport, Auth{ClientCert: certFile, ClientKey: filepath.Join(dir, "missing.key")}); err == nil {
+		t.Error("expected error with a missing key")
+	}
+}
@@ -0,0 +1,74 @@
+// Package client contains the functionality shared by the clients of the
+// Vulcan APIs.
+package client
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxBodyExcerpt is the maximum number of bytes of the body of a failed
+// response stored in a StatusError.
+const maxBodyExcerpt = 512
+
+var (
+	// ErrNotFound is matched by the errors returned when the requested
+	// resource does not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrUnauthorized is matched by the errors returned when the request is
+	// rejected because of missing or invalid credentials.
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrUpstream is matched by the errors returned when the API responds
+	// with any other non successful status code.
+	ErrUpstream = errors.New("upstream error")
+)
+
+// StatusError is returned when an API responds with a non successful status
+// code. It matches ErrNotFound, ErrUnauthorized or ErrUpstream depending on the
+// status code.
+type StatusError struct {
+	URL        string
+	StatusCode int
+	// Body contains an excerpt of the body of the response.
+	Body string
+}
+
+func (e *StatusError) Error() string {
+	msg := fmt.Sprintf("unexpected status code %d calling endpoint: %s", e.StatusCode, e.URL)
+	if e.Body != "" {
+		msg = msg + "\n" + e.Body
+	}
+	return msg
+}
+
+// Is allows to check the kind of a StatusError using errors.Is.
+func (e *StatusError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	case ErrUpstream:
+		return e.StatusCode != http.StatusNotFound &&
+			e.StatusCode != http.StatusUnauthorized &&
+			e.StatusCode != http.StatusForbidden
+	}
+	return false
+}
+
+// CheckResponse returns a *StatusError if the status code of the response is
+// not 2xx. It does not close the body of the response.
+func CheckResponse(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		return nil
+	}
+	excerpt, _ := io.ReadAll(io.LimitReader(resp.Body, maxBodyExcerpt))
+	return &StatusError{
+		URL:        resp.Request.URL.String(),
+		StatusCode: resp.StatusCode,
+		Body:       strings.TrimSpace(string(excerpt)),
+	}
+}
@@ -0,0 +1,95 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestStatusErrorIs(t *testing.T) {
+	tests := []struct {
+		name             string
+		statusCode       int
+		wantNotFound     bool
+		wantUnauthorized bool
+		wantUpstream     bool
+	}{
+		{name: "not found", statusCode: http.StatusNotFound, wantNotFound: true},
+		{name: "unauthorized", statusCode: http.StatusUnauthorized, wantUnauthorized: true},
+		{name: "forbidden", statusCode: http.StatusForbidden, wantUnauthorized: true},
+		{name: "server error", statusCode: http.StatusInternalServerError, wantUpstream: true},
+		{name: "bad request", statusCode: http.StatusBadRequest, wantUpstream: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The errors are usually wrapped by the callers.
+			err := fmt.Errorf("request: %w", &StatusError{URL: "http://vulcan", StatusCode: tt.statusCode})
+			if got := errors.Is(err, ErrNotFound); got != tt.wantNotFound {
+				t.Errorf("unexpected match of ErrNotFound: got %v, want %v", got, tt.wantNotFound)
+			}
+			if got := errors.Is(err, ErrUnauthorized); got != tt.wantUnauthorized {
+				t.Errorf("unexpected match of ErrUnauthorized: got %v, want %v", got, tt.wantUnauthorized)
+			}
+			if got := errors.Is(err, ErrUpstream); got != tt.wantUpstream {
+				t.Errorf("unexpected match of ErrUpstream: got %v, want %v", got, tt.wantUpstream)
+			}
+			if errors.Is(err, io.EOF) {
+				t.Error("unexpected match of another error")
+			}
+		})
+	}
+}
+
+func TestCheckResponse(t *testing.T) {
+	long := strings.Repeat("a", maxBodyExcerpt+10)
+
+	tests := []struct {
+		name       string
+		statusCode int
+		body       string
+		wantErr    *StatusError
+	}{
+		{name: "ok", statusCode: http.StatusOK, body: "{}"},
+		{name: "no content", statusCode: http.StatusNoContent},
+		{
+			name:       "not found",
+			statusCode: http.StatusNotFound,
+			body:       " scan not found\n",
+			wantErr:    &StatusError{URL: "http://vulcan/v1/scans/1", StatusCode: http.StatusNotFound, Body: "scan not found"},
+		},
+		{
+			name:       "long body",
+			statusCode: http.StatusBadGateway,
+			body:       long,
+			wantErr:    &StatusError{URL: "http://vulcan/v1/scans/1", StatusCode: http.StatusBadGateway, Body: long[:maxBodyExcerpt]},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, _ := url.Parse("http://vulcan/v1/scans/1")
+			resp := &http.Response{
+				StatusCode: tt.statusCode,
+				Body:       io.NopCloser(strings.NewReader(tt.body)),
+				Request:    &http.Request{URL: u},
+			}
+			err := CheckResponse(resp)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			var got *StatusError
+			if !errors.As(err, &got) {
+				t.Fatalf("unexpected error: got %v, want a *StatusError", err)
+			}
+			if *got != *tt.wantErr {
+				t.Errorf("unexpected error: got %+v, want %+v", got, tt.wantErr)
+			}
+		})
+	}
+}
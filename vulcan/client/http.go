@@ -0,0 +1,117 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// Version is the version of the tool sent in the User-Agent header. It is set
+// at build time using:
+//
+//	-ldflags "-X github.com/adevinta/security-overview/vulcan/client.Version=<version>"
+var Version = "dev"
+
+// UserAgent returns the value of the User-Agent header sent in every request.
+func UserAgent() string {
+	return "vulcan-security-overview/" + Version
+}
+
+// defMaxIdleConnsPerHost is higher than the default of the http package
+// because most of the requests are sent concurrently to the same host.
+const defMaxIdleConnsPerHost = 16
+
+// Options defines the configuration of the HTTP transport shared by all the
+// clients. Zero values mean the defaults of the http package.
+type Options struct {
+	// CABundle is the path to a PEM file with the certificates of the
+	// authorities trusted in addition to the ones of the system.
+	CABundle string
+	// Proxy is the URL of the proxy used for all the outbound requests. If
+	// it is empty, the proxy is taken from the environment.
+	Proxy               string
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	MaxConnsPerHost     int
+	IdleConnTimeout     time.Duration
+	DisableKeepAlives   bool
+	DisableCompression  bool
+}
+
+// NewTransport returns an HTTP transport configured with the given options,
+// meant to be shared by all the clients so they share the connection pool.
+func NewTransport(opts Options) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if opts.CABundle != "" {
+		pem, err := os.ReadFile(opts.CABundle)
+		if err != nil {
+			return nil, fmt.Errorf("reading CA bundle: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("no certificates found in CA bundle")
+		}
+		transport.TLSClientConfig = &tls.Config{
+			RootCAs:    pool,
+			MinVersion: tls.VersionTLS12,
+		}
+	}
+
+	if opts.Proxy != "" {
+		proxyURL, err := url.Parse(opts.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	transport.MaxIdleConnsPerHost = defMaxIdleConnsPerHost
+	if opts.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = opts.MaxIdleConnsPerHost
+	}
+	if opts.MaxIdleConns > 0 {
+		transport.MaxIdleConns = opts.MaxIdleConns
+	}
+	if opts.MaxConnsPerHost > 0 {
+		transport.MaxConnsPerHost = opts.MaxConnsPerHost
+	}
+	if opts.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = opts.IdleConnTimeout
+	}
+	transport.DisableKeepAlives = opts.DisableKeepAlives
+	transport.DisableCompression = opts.DisableCompression
+
+	return transport, nil
+}
+
+// New returns an HTTP client that uses the given transport and identifies
+// itself with the User-Agent of the tool.
+func New(transport *http.Transport) *http.Client {
+	return &http.Client{Transport: &userAgentTransport{base: transport}}
+}
+
+// userAgentTransport is an http.RoundTripper that sets the User-Agent header
+// of the requests. The User-Agent already set by other clients, like the AWS
+// SDK, is kept after the one of the tool.
+type userAgentTransport struct {
+	base http.RoundTripper
+}
+
+func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	ua := UserAgent()
+	if current := req.Header.Get("User-Agent"); current != "" {
+		ua = ua + " " + current
+	}
+	req.Header.Set("User-Agent", ua)
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,136 @@
+package client
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewTransportCABundle(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	bundle := filepath.Join(dir, "ca.pem")
+	if err := os.WriteFile(bundle, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	invalid := filepath.Join(dir, "invalid.pem")
+	if err := os.WriteFile(invalid, []byte("not a certificate"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		caBundle    string
+		wantErr     bool
+		wantTrusted bool
+	}{
+		{name: "system authorities", caBundle: "", wantTrusted: false},
+		{name: "CA bundle", caBundle: bundle, wantTrusted: true},
+		{name: "missing CA bundle", caBundle: filepath.Join(dir, "missing.pem"), wantErr: true},
+		{name: "invalid CA bundle", caBundle: invalid, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport, err := NewTransport(Options{CABundle: tt.caBundle})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err != nil {
+				return
+			}
+			resp, err := New(transport).Get(srv.URL)
+			if err == nil {
+				resp.Body.Close()
+			}
+			if trusted := err == nil; trusted != tt.wantTrusted {
+				t.Errorf("unexpected trusted server: got %v, want %v: %v", trusted, tt.wantTrusted, err)
+			}
+		})
+	}
+}
+
+func TestNewTransportProxy(t *testing.T) {
+	var got string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.URL.String()
+	}))
+	defer proxy.Close()
+
+	transport, err := NewTransport(Options{Proxy: proxy.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := New(transport).Get("http://vulcan.example.com/v1/scans")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	// The proxy receives the request for the original URL.
+	if want := "http://vulcan.example.com/v1/scans"; got != want {
+		t.Errorf("unexpected proxied URL: got %q, want %q", got, want)
+	}
+
+	if _, err := NewTransport(Options{Proxy: "://proxy"}); err == nil {
+		t.Error("expected error with an invalid proxy")
+	}
+}
+
+func TestNewTransportDefaults(t *testing.T) {
+	transport, err := NewTransport(Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transport.MaxIdleConnsPerHost != defMaxIdleConnsPerHost {
+		t.Errorf("unexpected max idle connections per host: got %d, want %d", transport.MaxIdleConnsPerHost, defMaxIdleConnsPerHost)
+	}
+	// The transport of the http package is not modified.
+	if http.DefaultTransport.(*http.Transport) == transport {
+		t.Error("the default transport is shared")
+	}
+}
+
+func TestUserAgentTransport(t *testing.T) {
+	tests := []struct {
+		name      string
+		userAgent string
+		want      string
+	}{
+		{name: "no User-Agent", userAgent: "", want: UserAgent()},
+		{name: "other User-Agent", userAgent: "aws-sdk-go/1.44.0", want: UserAgent() + " aws-sdk-go/1.44.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.Header.Get("User-Agent")
+			}))
+			defer srv.Close()
+
+			req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.userAgent != "" {
+				req.Header.Set("User-Agent", tt.userAgent)
+			}
+			resp, err := New(http.DefaultTransport.(*http.Transport).Clone()).Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			if got != tt.want {
+				t.Errorf("unexpected User-Agent: got %q, want %q", got, tt.want)
+			}
+			// The request of the caller is not modified.
+			if ua := req.Header.Get("User-Agent"); ua != tt.userAgent {
+				t.Errorf("unexpected User-Agent of the request: got %q, want %q", ua, tt.userAgent)
+			}
+		})
+	}
+}
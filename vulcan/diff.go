@@ -0,0 +1,79 @@
+package vulcan
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+)
+
+// Diff contains the changes in the findings of a scan since a previous scan.
+type Diff struct {
+	PreviousScanID string `json:"previous_scan_id"`
+	PreviousDate   string `json:"previous_date"`
+	// New contains the findings not found in the previous scan.
+	New []Vulnerability `json:"new"`
+	// Fixed contains the findings of the previous scan not found anymore.
+	Fixed []Vulnerability `json:"fixed"`
+	// Open contains the findings found in both scans.
+	Open []Vulnerability `json:"open"`
+}
+
+// FindingKey returns the key identifying a finding across scans: the asset,
+// the checktype and the fingerprint of the vulnerability. The vulnerabilities
+// without fingerprint are identified by their summary and affected resource.
+func FindingKey(v Vulnerability) string {
+	id := v.Vulnerability.Fingerprint
+	if id == "" {
+		id = v.Vulnerability.Summary + "|" + v.Vulnerability.AffectedResource
+	}
+	return strings.Join([]string{v.Asset, v.CheckType, id}, "|")
+}
+
+// DiffScans classifies the findings of the current scan and the previous one
+// as new, fixed or still open. The findings keep the order they have in the
+// report data.
+func DiffScans(current, previous *ReportData) *Diff {
+	d := &Diff{
+		PreviousScanID: previous.ScanID,
+		PreviousDate:   previous.Date,
+		New:            []Vulnerability{},
+		Fixed:          []Vulnerability{},
+		Open:           []Vulnerability{},
+	}
+
+	previousKeys := make(map[string]bool)
+	for _, v := range previous.Vulnerabilities {
+		previousKeys[FindingKey(v)] = true
+	}
+	currentKeys := make(map[string]bool)
+	for _, v := range current.Vulnerabilities {
+		key := FindingKey(v)
+		currentKeys[key] = true
+		if previousKeys[key] {
+			d.Open = append(d.Open, v)
+		} else {
+			d.New = append(d.New, v)
+		}
+	}
+	for _, v := range previous.Vulnerabilities {
+		if !currentKeys[FindingKey(v)] {
+			d.Fixed = append(d.Fixed, v)
+		}
+	}
+
+	return d
+}
+
+// ReadReportData reads the report data stored in a JSON file, like the one
+// written when a report is generated.
+func ReadReportData(path string) (*ReportData, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	rp := &ReportData{}
+	if err := json.Unmarshal(content, rp); err != nil {
+		return nil, err
+	}
+	return rp, nil
+}
@@ -0,0 +1,101 @@
+package vulcan
+
+import (
+	"reflect"
+	"testing"
+
+	vulcanreport "github.com/adevinta/vulcan-report"
+)
+
+func TestFindingKey(t *testing.T) {
+	tests := []struct {
+		name string
+		v    Vulnerability
+		want string
+	}{
+		{
+			name: "fingerprint",
+			v:    Vulnerability{Asset: "example.com", CheckType: "vulcan-tls", Vulnerability: vulcanreport.Vulnerability{Summary: "Weak TLS", Fingerprint: "abc"}},
+			want: "example.com|vulcan-tls|abc",
+		},
+		{
+			name: "no fingerprint",
+			v:    Vulnerability{Asset: "example.com", CheckType: "vulcan-tls", Vulnerability: vulcanreport.Vulnerability{Summary: "Weak TLS", AffectedResource: "443/tcp"}},
+			want: "example.com|vulcan-tls|Weak TLS|443/tcp",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FindingKey(tt.v); got != tt.want {
+				t.Errorf("unexpected key: got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffScans(t *testing.T) {
+	finding := func(asset, summary string) Vulnerability {
+		return Vulnerability{Asset: asset, CheckType: "vulcan-tls", Vulnerability: vulcanreport.Vulnerability{Summary: summary}}
+	}
+	weakTLS := finding("example.com", "Weak TLS")
+	expired := finding("example.com", "Expired certificate")
+	otherAsset := finding("example.org", "Weak TLS")
+
+	tests := []struct {
+		name      string
+		current   []Vulnerability
+		previous  []Vulnerability
+		wantNew   []Vulnerability
+		wantFixed []Vulnerability
+		wantOpen  []Vulnerability
+	}{
+		{
+			name:      "no findings",
+			wantNew:   []Vulnerability{},
+			wantFixed: []Vulnerability{},
+			wantOpen:  []Vulnerability{},
+		},
+		{
+			name:      "first scan",
+			current:   []Vulnerability{weakTLS, expired},
+			wantNew:   []Vulnerability{weakTLS, expired},
+			wantFixed: []Vulnerability{},
+			wantOpen:  []Vulnerability{},
+		},
+		{
+			name:      "new, fixed and open",
+			current:   []Vulnerability{weakTLS, otherAsset},
+			previous:  []Vulnerability{expired, weakTLS},
+			wantNew:   []Vulnerability{otherAsset},
+			wantFixed: []Vulnerability{expired},
+			wantOpen:  []Vulnerability{weakTLS},
+		},
+		{
+			name:      "all fixed",
+			previous:  []Vulnerability{weakTLS, expired},
+			wantNew:   []Vulnerability{},
+			wantFixed: []Vulnerability{weakTLS, expired},
+			wantOpen:  []Vulnerability{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current := &ReportData{ScanID: "current", Date: "2022-10-15", Vulnerabilities: tt.current}
+			previous := &ReportData{ScanID: "previous", Date: "2022-10-08", Vulnerabilities: tt.previous}
+			d := DiffScans(current, previous)
+
+			if d.PreviousScanID != "previous" || d.PreviousDate != "2022-10-08" {
+				t.Errorf("unexpected previous scan: %s %s", d.PreviousScanID, d.PreviousDate)
+			}
+			if !reflect.DeepEqual(d.New, tt.wantNew) {
+				t.Errorf("unexpected new findings: got %+v, want %+v", d.New, tt.wantNew)
+			}
+			if !reflect.DeepEqual(d.Fixed, tt.wantFixed) {
+				t.Errorf("unexpected fixed findings: got %+v, want %+v", d.Fixed, tt.wantFixed)
+			}
+			if !reflect.DeepEqual(d.Open, tt.wantOpen) {
+				t.Errorf("unexpected open findings: got %+v, want %+v", d.Open, tt.wantOpen)
+			}
+		})
+	}
+}
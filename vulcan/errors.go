@@ -0,0 +1,23 @@
+package vulcan
+
+import "fmt"
+
+// PartialCollectionError is returned when the collection of the reports of a
+// scan is interrupted, because of a cancellation or a deadline, before all of
+// them are fetched.
+type PartialCollectionError struct {
+	ScanID    string
+	Collected int
+	// Total is the number of checks of the scan or, if the source does not
+	// provide it, the number of checks retrieved before the interruption.
+	Total int
+	Err   error
+}
+
+func (e *PartialCollectionError) Error() string {
+	return fmt.Sprintf("collection of scan %s interrupted after fetching %d of %d reports: %v", e.ScanID, e.Collected, e.Total, e.Err)
+}
+
+func (e *PartialCollectionError) Unwrap() error {
+	return e.Err
+}
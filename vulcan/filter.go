@@ -0,0 +1,59 @@
+package vulcan
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+)
+
+// FindingFilter defines the criteria matching a finding. A finding matches
+// the filter if it matches all the criteria defined in it.
+type FindingFilter struct {
+	// Asset is a glob matching the asset, with the syntax of path.Match.
+	Asset     string `toml:"asset" json:"asset,omitempty"`
+	CheckType string `toml:"checktype" json:"checktype,omitempty"`
+	// Summary is a regular expression matching the summary of the
+	// vulnerability.
+	Summary     string `toml:"summary" json:"summary,omitempty"`
+	Fingerprint string `toml:"fingerprint" json:"fingerprint,omitempty"`
+
+	summary *regexp.Regexp
+}
+
+func (f *FindingFilter) compile() error {
+	if f.Asset == "" && f.CheckType == "" && f.Summary == "" && f.Fingerprint == "" {
+		return errors.New("no finding criteria")
+	}
+	if f.Asset != "" {
+		if _, err := path.Match(f.Asset, ""); err != nil {
+			return fmt.Errorf("invalid asset glob: %w", err)
+		}
+	}
+	if f.Summary != "" {
+		re, err := regexp.Compile(f.Summary)
+		if err != nil {
+			return fmt.Errorf("invalid summary: %w", err)
+		}
+		f.summary = re
+	}
+	return nil
+}
+
+func (f FindingFilter) match(v Vulnerability) bool {
+	if f.Asset != "" {
+		if ok, _ := path.Match(f.Asset, v.Asset); !ok {
+			return false
+		}
+	}
+	if f.CheckType != "" && f.CheckType != v.CheckType {
+		return false
+	}
+	if f.summary != nil && !f.summary.MatchString(v.Vulnerability.Summary) {
+		return false
+	}
+	if f.Fingerprint != "" && f.Fingerprint != v.Vulnerability.Fingerprint {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,90 @@
+package vulcan
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/adevinta/vulcan-groupie/db"
+	"github.com/adevinta/vulcan-groupie/pkg/models"
+	vulcanreport "github.com/adevinta/vulcan-report"
+)
+
+const (
+	// GroupieMemory identifies the grouping database kept in memory, so the
+	// groups only include the scan of the report.
+	GroupieMemory = "memory"
+	// GroupieFile identifies the grouping database stored in a local file,
+	// so the groups accumulate across scans.
+	GroupieFile = "file"
+)
+
+// FileDB is a vulcan-groupie database stored in a local file. The state is
+// loaded when the database is opened and written by Save. It is not safe to
+// use it from concurrent processes.
+type FileDB struct {
+	*db.MemDB
+	path string
+	keep int
+}
+
+// OpenFileDB opens the database stored in the given file, or an empty one if
+// the file does not exist. When the state is saved, only the last keep scans
+// of every check are kept. A non positive keep means all of them.
+func OpenFileDB(path string, keep int) (*FileDB, error) {
+	m, err := db.LoadState(path)
+	if errors.Is(err, os.ErrNotExist) {
+		m, err = db.NewMemDB(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &FileDB{MemDB: m, path: path, keep: keep}, nil
+}
+
+// Save writes the state of the database to its file.
+func (f *FileDB) Save() error {
+	if f.keep > 0 {
+		for key, entries := range f.Historic {
+			if len(entries) > f.keep {
+				f.Historic[key] = entries[len(entries)-f.keep:]
+			}
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(f.path), 0700); err != nil {
+		return err
+	}
+	// The state is written to a temporary file that replaces the previous
+	// one, so it is not lost if the process is interrupted.
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmp.Close()
+	if err := f.SaveState(tmp.Name()); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), f.path)
+}
+
+// groupedKey returns the key of the findings of an asset merged by the
+// grouping database into the same vulnerability: the ones of the same
+// checktype with the same summary and severity.
+func groupedKey(asset, checktype string, v vulcanreport.Vulnerability) string {
+	return fmt.Sprintf("%s|%s|%s|%d", asset, checktype, v.Summary, v.Severity())
+}
+
+// GroupedFindings returns the findings of the asset merged into the given
+// vulnerability of the grouping database, which only keeps the one with the
+// highest score of all the assets. If they are not found, for instance because
+// the vulnerability was stored by a previous scan, the vulnerability itself is
+// returned as the finding of the asset.
+func (rp *ReportData) GroupedFindings(asset string, v models.Vulnerability) []Vulnerability {
+	if findings, ok := rp.grouped[groupedKey(asset, v.Checktype, v.Vulnerability)]; ok {
+		return findings
+	}
+	return []Vulnerability{{Asset: asset, CheckType: v.Checktype, Vulnerability: v.Vulnerability}}
+}
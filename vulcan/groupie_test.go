@@ -0,0 +1,117 @@
+package vulcan
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/adevinta/vulcan-groupie/db"
+	"github.com/adevinta/vulcan-groupie/pkg/groupie"
+	"github.com/adevinta/vulcan-groupie/pkg/models"
+	vulcanreport "github.com/adevinta/vulcan-report"
+)
+
+func TestFileDB(t *testing.T) {
+	tests := []struct {
+		name        string
+		keep        int
+		scans       int
+		wantEntries int
+	}{
+		{name: "keep all", keep: 0, scans: 3, wantEntries: 3},
+		{name: "keep last", keep: 2, scans: 3, wantEntries: 2},
+		{name: "fewer scans", keep: 5, scans: 3, wantEntries: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "groupie", "team.gob")
+			f, err := OpenFileDB(path, tt.keep)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := newTestReport("example.com", "vulcan-tls", vulcanreport.Vulnerability{Summary: "Weak TLS", Score: 6.9})
+			for i := 0; i < tt.scans; i++ {
+				date := fmt.Sprintf("2022-10-%02d", i+1)
+				if err := f.SaveScanVulnerabilities(fmt.Sprintf("scan%d", i), date, []vulcanreport.Report{*r}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := f.Save(); err != nil {
+				t.Fatal(err)
+			}
+
+			// The state is kept across runs.
+			f, err = OpenFileDB(path, tt.keep)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(f.Historic) != 1 {
+				t.Fatalf("unexpected number of checks: got %d, want 1", len(f.Historic))
+			}
+			for key, entries := range f.Historic {
+				if len(entries) != tt.wantEntries {
+					t.Errorf("unexpected number of scans of %s: got %d, want %d", key, len(entries), tt.wantEntries)
+				}
+			}
+			// The last scan is always kept.
+			vulns, err := f.GetScanVulnerabilities(fmt.Sprintf("scan%d", tt.scans-1))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(vulns) != 1 {
+				t.Errorf("unexpected vulnerabilities of the last scan: %+v", vulns)
+			}
+		})
+	}
+}
+
+func TestGroupedFindings(t *testing.T) {
+	a := newAggregator("scan", "2022-10-12", groupie.New(db.NewMemDB()), nil, nil, nil)
+	a.add(context.Background(), newTestReport("example.com", "vulcan-tls",
+		vulcanreport.Vulnerability{Summary: "Weak TLS", Score: 6.9, AffectedResource: "443"},
+		vulcanreport.Vulnerability{Summary: "Weak TLS", Score: 5.0, AffectedResource: "8443"},
+		vulcanreport.Vulnerability{Summary: "Weak TLS", Score: 3.9, AffectedResource: "9443"},
+	), AssetTypeHostname)
+	rd := &ReportData{}
+	if err := a.apply(rd); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		asset     string
+		v         models.Vulnerability
+		wantPorts []string
+	}{
+		{
+			name:      "same severity",
+			asset:     "example.com",
+			v:         models.Vulnerability{Checktype: "vulcan-tls", Vulnerability: vulcanreport.Vulnerability{Summary: "Weak TLS", Score: 6.9, AffectedResource: "443"}},
+			wantPorts: []string{"443", "8443"},
+		},
+		{
+			name:      "not found",
+			asset:     "example.org",
+			v:         models.Vulnerability{Checktype: "vulcan-tls", Vulnerability: vulcanreport.Vulnerability{Summary: "Weak TLS", Score: 6.9, AffectedResource: "443"}},
+			wantPorts: []string{"443"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ports []string
+			for _, f := range rd.GroupedFindings(tt.asset, tt.v) {
+				if f.Asset != tt.asset || f.CheckType != "vulcan-tls" {
+					t.Errorf("unexpected finding: %+v", f)
+				}
+				ports = append(ports, f.Vulnerability.AffectedResource)
+			}
+			sort.Strings(ports)
+			if !reflect.DeepEqual(ports, tt.wantPorts) {
+				t.Errorf("unexpected findings: got %v, want %v", ports, tt.wantPorts)
+			}
+		})
+	}
+}
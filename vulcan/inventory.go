@@ -0,0 +1,266 @@
+package vulcan
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// unknownValue is the value of the breakdowns grouping the assets without
+// metadata.
+const unknownValue = "Unknown"
+
+// AssetMetadata contains the information about an asset kept in the
+// inventory of the team.
+type AssetMetadata struct {
+	Asset       string   `json:"asset"`
+	Type        string   `json:"type,omitempty"` // Hostname, IP, DockerImage, AWSAccount, GitRepository...
+	Environment string   `json:"environment,omitempty"`
+	Owner       string   `json:"owner,omitempty"`
+	Criticality string   `json:"criticality,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+// Inventory provides the metadata of the assets.
+type Inventory interface {
+	// Metadata returns the metadata of the given assets, indexed by asset.
+	// The assets not found in the inventory are not returned.
+	Metadata(ctx context.Context, assets []string) (map[string]AssetMetadata, error)
+}
+
+// MapInventory is an inventory kept in memory, indexed by asset. It can stand
+// in for the inventory of the Vulcan API.
+type MapInventory map[string]AssetMetadata
+
+// Metadata returns the metadata of the given assets.
+func (m MapInventory) Metadata(ctx context.Context, assets []string) (map[string]AssetMetadata, error) {
+	result := make(map[string]AssetMetadata)
+	for _, asset := range assets {
+		if md, ok := m[asset]; ok {
+			result[asset] = md
+		}
+	}
+	return result, nil
+}
+
+// ReadInventory reads the inventory stored in a JSON file, containing an
+// array of AssetMetadata, or in a CSV file, with a header naming the columns:
+// asset, type, environment, owner, criticality and tags. The tags are
+// separated by semicolons.
+func ReadInventory(path string) (MapInventory, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []AssetMetadata
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.NewDecoder(f).Decode(&entries)
+	case ".csv":
+		entries, err = readInventoryCSV(f)
+	default:
+		return nil, fmt.Errorf("unknown inventory format: %s", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid inventory %s: %w", path, err)
+	}
+
+	inv := make(MapInventory)
+	for _, md := range entries {
+		if md.Asset == "" {
+			return nil, fmt.Errorf("invalid inventory %s: asset without name", path)
+		}
+		inv[md.Asset] = md
+	}
+	return inv, nil
+}
+
+func readInventoryCSV(r io.Reader) ([]AssetMetadata, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, errors.New("missing header")
+	}
+
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["asset"]; !ok {
+		return nil, errors.New("missing asset column")
+	}
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var entries []AssetMetadata
+	for _, record := range records[1:] {
+		md := AssetMetadata{
+			Asset:       field(record, "asset"),
+			Type:        field(record, "type"),
+			Environment: field(record, "environment"),
+			Owner:       field(record, "owner"),
+			Criticality: field(record, "criticality"),
+		}
+		for _, tag := range strings.Split(field(record, "tags"), ";") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				md.Tags = append(md.Tags, tag)
+			}
+		}
+		entries = append(entries, md)
+	}
+	return entries, nil
+}
+
+// EnrichAssets sets the metadata of the assets of the report data provided by
+// the given inventory. The assets not found in it have no metadata but their
+// type.
+func (rp *ReportData) EnrichAssets(ctx context.Context, inv Inventory) error {
+	metadata, err := inv.Metadata(ctx, rp.Assets)
+	if err != nil {
+		return err
+	}
+	rp.AssetsMetadata = make(map[string]AssetMetadata)
+	if rp.AssetTypes == nil {
+		rp.AssetTypes = make(map[string]string)
+	}
+	for _, asset := range rp.Assets {
+		md := metadata[asset]
+		md.Asset = asset
+		// The type kept in the inventory takes precedence over the one
+		// stored in the check data or inferred from the asset.
+		if md.Type != "" {
+			rp.AssetTypes[asset] = md.Type
+		}
+		md.Type = rp.AssetTypes[asset]
+		rp.AssetsMetadata[asset] = md
+	}
+	// The findings keep the type of their asset.
+	for i, v := range rp.Vulnerabilities {
+		rp.Vulnerabilities[i].AssetType = rp.AssetTypes[v.Asset]
+	}
+	for i, s := range rp.Suppressed {
+		rp.Suppressed[i].AssetType = rp.AssetTypes[s.Asset]
+	}
+	return nil
+}
+
+// AssetBreakdown groups the assets by one of the fields of their metadata.
+type AssetBreakdown struct {
+	Field  string       `json:"field"`
+	Groups []AssetGroup `json:"groups"`
+}
+
+// AssetGroup contains the assets with the same value of a field of their
+// metadata, and the number of vulnerabilities per impact found in them.
+type AssetGroup struct {
+	Value                    string         `json:"value"`
+	Assets                   []string       `json:"assets"`
+	VulnerableAssets         int            `json:"vulnerable_assets"`
+	VulnerabilitiesPerImpact map[string]int `json:"vulnerabilities_per_impact"`
+}
+
+// Issues returns the number of vulnerabilities with an impact over Info.
+func (g AssetGroup) Issues() int {
+	return countIssues(g.VulnerabilitiesPerImpact)
+}
+
+// AssetBreakdowns groups the assets of the report data by every field of
+// their metadata. The fields no asset has a value for are omitted, so it
+// returns no breakdowns if the assets have not been enriched. The types of the
+// assets are summarized by AssetTypeSummaries instead.
+func (rp *ReportData) AssetBreakdowns() []AssetBreakdown {
+	fields := []struct {
+		name   string
+		values func(AssetMetadata) []string
+	}{
+		{"Environment", func(md AssetMetadata) []string { return []string{md.Environment} }},
+		{"Owner", func(md AssetMetadata) []string { return []string{md.Owner} }},
+		{"Criticality", func(md AssetMetadata) []string { return []string{md.Criticality} }},
+		{"Tag", func(md AssetMetadata) []string { return md.Tags }},
+	}
+
+	perAsset := rp.issuesPerAsset()
+	result := []AssetBreakdown{}
+	for _, field := range fields {
+		values := field.values
+		groups, known := groupAssets(rp.Assets, perAsset, func(asset string) []string {
+			return values(rp.AssetsMetadata[asset])
+		})
+		if !known {
+			continue
+		}
+		result = append(result, AssetBreakdown{Field: field.name, Groups: groups})
+	}
+	return result
+}
+
+// issuesPerAsset returns the number of vulnerabilities per impact found in
+// every asset.
+func (rp *ReportData) issuesPerAsset() map[string]map[string]int {
+	perAsset := make(map[string]map[string]int)
+	for _, v := range rp.Vulnerabilities {
+		if perAsset[v.Asset] == nil {
+			perAsset[v.Asset] = make(map[string]int)
+		}
+		perAsset[v.Asset][severityToString(v.Vulnerability.Severity())]++
+	}
+	return perAsset
+}
+
+// groupAssets groups the given assets by the values returned for each of
+// them. The assets without values are grouped as unknown, and known reports
+// whether any asset has a value.
+func groupAssets(assets []string, perAsset map[string]map[string]int, valuesOf func(asset string) []string) (result []AssetGroup, known bool) {
+	groups := make(map[string]*AssetGroup)
+	for _, asset := range assets {
+		values := valuesOf(asset)
+		if len(values) == 0 || (len(values) == 1 && values[0] == "") {
+			values = []string{unknownValue}
+		} else {
+			known = true
+		}
+		for _, value := range values {
+			g, ok := groups[value]
+			if !ok {
+				g = &AssetGroup{Value: value, VulnerabilitiesPerImpact: make(map[string]int)}
+				groups[value] = g
+			}
+			g.Assets = append(g.Assets, asset)
+			if len(perAsset[asset]) > 0 {
+				g.VulnerableAssets++
+			}
+			for impact, count := range perAsset[asset] {
+				g.VulnerabilitiesPerImpact[impact] += count
+			}
+		}
+	}
+
+	result = []AssetGroup{}
+	for _, g := range groups {
+		result = append(result, *g)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		// The assets without a value are shown last.
+		if (result[i].Value == unknownValue) != (result[j].Value == unknownValue) {
+			return result[j].Value == unknownValue
+		}
+		return result[i].Value < result[j].Value
+	})
+	return result, known
+}
@@ -0,0 +1,99 @@
+package vulcan
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	vulcanreport "github.com/adevinta/vulcan-report"
+)
+
+func TestReadInventory(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    MapInventory
+		wantErr bool
+	}{
+		{
+			name:    "json",
+			file:    "inventory.json",
+			content: `[{"asset": "example.com", "environment": "production", "tags": ["web"]}]`,
+			want: MapInventory{
+				"example.com": {Asset: "example.com", Environment: "production", Tags: []string{"web"}},
+			},
+		},
+		{
+			name:    "csv",
+			file:    "inventory.csv",
+			content: "Asset,Owner,Criticality,Tags\nexample.com,web-team,high, web ; public \n10.0.0.1,,,\n",
+			want: MapInventory{
+				"example.com": {Asset: "example.com", Owner: "web-team", Criticality: "high", Tags: []string{"web", "public"}},
+				"10.0.0.1":    {Asset: "10.0.0.1"},
+			},
+		},
+		{name: "csv without asset column", file: "inventory.csv", content: "owner\nweb-team\n", wantErr: true},
+		{name: "empty csv", file: "inventory.csv", content: "", wantErr: true},
+		{name: "asset without name", file: "inventory.json", content: `[{"owner": "web-team"}]`, wantErr: true},
+		{name: "unknown format", file: "inventory.yaml", content: "- asset: example.com\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, map[string]string{tt.file: tt.content})
+			got, err := ReadInventory(filepath.Join(dir, tt.file))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("unexpected inventory: got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAssetBreakdowns(t *testing.T) {
+	rd := &ReportData{
+		Assets: []string{"a.example.com", "b.example.com", "c.example.com"},
+		Vulnerabilities: []Vulnerability{
+			{Asset: "a.example.com", Vulnerability: vulcanreport.Vulnerability{Summary: "Weak TLS", Score: 6.9}},
+			{Asset: "c.example.com", Vulnerability: vulcanreport.Vulnerability{Summary: "Open port", Score: 3.9}},
+		},
+	}
+	inv := MapInventory{
+		"a.example.com": {Asset: "a.example.com", Environment: "production", Tags: []string{"web", "public"}},
+		"b.example.com": {Asset: "b.example.com", Environment: "staging"},
+	}
+	if err := rd.EnrichAssets(context.Background(), inv); err != nil {
+		t.Fatal(err)
+	}
+	if md := rd.AssetsMetadata["c.example.com"]; md.Asset != "c.example.com" || md.Environment != "" {
+		t.Errorf("unexpected metadata of an asset not in the inventory: %+v", md)
+	}
+
+	// The fields without values are omitted and the assets without a value
+	// are grouped last.
+	want := []AssetBreakdown{
+		{
+			Field: "Environment",
+			Groups: []AssetGroup{
+				{Value: "production", Assets: []string{"a.example.com"}, VulnerableAssets: 1, VulnerabilitiesPerImpact: map[string]int{"Medium": 1}},
+				{Value: "staging", Assets: []string{"b.example.com"}, VulnerabilitiesPerImpact: map[string]int{}},
+				{Value: unknownValue, Assets: []string{"c.example.com"}, VulnerableAssets: 1, VulnerabilitiesPerImpact: map[string]int{"Low": 1}},
+			},
+		},
+		{
+			Field: "Tag",
+			Groups: []AssetGroup{
+				{Value: "public", Assets: []string{"a.example.com"}, VulnerableAssets: 1, VulnerabilitiesPerImpact: map[string]int{"Medium": 1}},
+				{Value: "web", Assets: []string{"a.example.com"}, VulnerableAssets: 1, VulnerabilitiesPerImpact: map[string]int{"Medium": 1}},
+				{Value: unknownValue, Assets: []string{"b.example.com", "c.example.com"}, VulnerableAssets: 1, VulnerabilitiesPerImpact: map[string]int{"Low": 1}},
+			},
+		},
+	}
+	if got := rd.AssetBreakdowns(); !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected breakdowns: got %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,81 @@
+package vulcan
+
+import (
+	"time"
+
+	"github.com/adevinta/security-overview/config"
+	vulcanreport "github.com/adevinta/vulcan-report"
+)
+
+// dateLayout is the layout of the dates of the scans.
+const dateLayout = "2006-01-02"
+
+// FindingDates contains the dates when a finding was found for the first and
+// the last time.
+type FindingDates struct {
+	FirstSeen string `json:"first_seen"`
+	LastSeen  string `json:"last_seen"`
+}
+
+// OpenDays returns the number of days between the date when a finding was
+// found for the first time and the given date. Invalid dates count as zero
+// days.
+func OpenDays(firstSeen, date string) int {
+	from, err := time.Parse(dateLayout, firstSeen)
+	if err != nil {
+		return 0
+	}
+	to, err := time.Parse(dateLayout, date)
+	if err != nil || to.Before(from) {
+		return 0
+	}
+	return int(to.Sub(from).Hours() / 24)
+}
+
+// slaDays returns the number of days the findings of the given severity can
+// be open, or zero if there is no SLA for them.
+func slaDays(sla config.SLAConfig, severity vulcanreport.SeverityRank) int {
+	switch severity {
+	case vulcanreport.SeverityCritical:
+		return sla.Critical
+	case vulcanreport.SeverityHigh:
+		return sla.High
+	case vulcanreport.SeverityMedium:
+		return sla.Medium
+	case vulcanreport.SeverityLow:
+		return sla.Low
+	}
+	return 0
+}
+
+// SetFindingDates sets the dates of the findings of the report data, indexed
+// by the key returned by FindingKey, and the lifecycle of every vulnerability
+// according to the given SLA.
+func (rp *ReportData) SetFindingDates(dates map[string]FindingDates, sla config.SLAConfig) {
+	rp.findingDates = dates
+	rp.sla = sla
+	for i := range rp.Vulnerabilities {
+		rp.SetLifecycle(&rp.Vulnerabilities[i], rp.Vulnerabilities[i])
+	}
+}
+
+// SetLifecycle sets the lifecycle of a vulnerability from the oldest of the
+// given findings, so the vulnerabilities grouping several findings are open
+// since the first of them was found.
+func (rp *ReportData) SetLifecycle(v *Vulnerability, findings ...Vulnerability) {
+	for _, f := range findings {
+		dates, ok := rp.findingDates[FindingKey(f)]
+		if !ok || dates.FirstSeen == "" {
+			continue
+		}
+		if v.FirstSeen == "" || dates.FirstSeen < v.FirstSeen {
+			v.FirstSeen = dates.FirstSeen
+		}
+	}
+	if v.FirstSeen == "" {
+		return
+	}
+	v.OpenDays = OpenDays(v.FirstSeen, rp.Date)
+	days := slaDays(rp.sla, v.Vulnerability.Severity())
+	v.SLABreached = days > 0 && v.OpenDays > days
+}
@@ -0,0 +1,96 @@
+package vulcan
+
+import (
+	"testing"
+
+	"github.com/adevinta/security-overview/config"
+	vulcanreport "github.com/adevinta/vulcan-report"
+)
+
+func TestOpenDays(t *testing.T) {
+	tests := []struct {
+		name      string
+		firstSeen string
+		date      string
+		want      int
+	}{
+		{name: "same day", firstSeen: "2022-10-12", date: "2022-10-12", want: 0},
+		{name: "days", firstSeen: "2022-10-01", date: "2022-10-12", want: 11},
+		{name: "leap year", firstSeen: "2024-02-28", date: "2024-03-01", want: 2},
+		{name: "future", firstSeen: "2022-10-12", date: "2022-10-01", want: 0},
+		{name: "invalid first seen", firstSeen: "", date: "2022-10-12", want: 0},
+		{name: "invalid date", firstSeen: "2022-10-12", date: "12/10/2022", want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := OpenDays(tt.firstSeen, tt.date); got != tt.want {
+				t.Errorf("unexpected days: got %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetFindingDates(t *testing.T) {
+	sla := config.SLAConfig{Critical: 7, High: 30, Medium: 90}
+	finding := func(asset string, score float32) Vulnerability {
+		return Vulnerability{Asset: asset, CheckType: "vulcan-check", Vulnerability: vulcanreport.Vulnerability{Summary: "Vulnerability", Score: score}}
+	}
+
+	tests := []struct {
+		name            string
+		v               Vulnerability
+		firstSeen       string // Empty if the finding has no dates.
+		wantFirstSeen   string
+		wantOpenDays    int
+		wantSLABreached bool
+	}{
+		{name: "unknown", v: finding("a.example.com", 9.5)},
+		{name: "new", v: finding("b.example.com", 9.5), firstSeen: "2022-10-12", wantFirstSeen: "2022-10-12"},
+		{name: "within SLA", v: finding("c.example.com", 9.5), firstSeen: "2022-10-05", wantFirstSeen: "2022-10-05", wantOpenDays: 7},
+		{name: "SLA breached", v: finding("d.example.com", 9.5), firstSeen: "2022-10-04", wantFirstSeen: "2022-10-04", wantOpenDays: 8, wantSLABreached: true},
+		{name: "high", v: finding("e.example.com", 8.0), firstSeen: "2022-09-01", wantFirstSeen: "2022-09-01", wantOpenDays: 41, wantSLABreached: true},
+		{name: "medium", v: finding("f.example.com", 5.0), firstSeen: "2022-09-01", wantFirstSeen: "2022-09-01", wantOpenDays: 41},
+		{name: "no SLA", v: finding("g.example.com", 2.0), firstSeen: "2021-10-12", wantFirstSeen: "2021-10-12", wantOpenDays: 365},
+	}
+	rd := &ReportData{Date: "2022-10-12"}
+	dates := make(map[string]FindingDates)
+	for _, tt := range tests {
+		rd.Vulnerabilities = append(rd.Vulnerabilities, tt.v)
+		if tt.firstSeen != "" {
+			dates[FindingKey(tt.v)] = FindingDates{FirstSeen: tt.firstSeen, LastSeen: rd.Date}
+		}
+	}
+	rd.SetFindingDates(dates, sla)
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := rd.Vulnerabilities[i]
+			if v.FirstSeen != tt.wantFirstSeen {
+				t.Errorf("unexpected first seen: got %q, want %q", v.FirstSeen, tt.wantFirstSeen)
+			}
+			if v.OpenDays != tt.wantOpenDays {
+				t.Errorf("unexpected open days: got %d, want %d", v.OpenDays, tt.wantOpenDays)
+			}
+			if v.SLABreached != tt.wantSLABreached {
+				t.Errorf("unexpected SLA breached: got %v, want %v", v.SLABreached, tt.wantSLABreached)
+			}
+		})
+	}
+}
+
+func TestSetLifecycleGroup(t *testing.T) {
+	a := Vulnerability{Asset: "a.example.com", CheckType: "vulcan-tls", Vulnerability: vulcanreport.Vulnerability{Summary: "Weak TLS", Score: 6.9}}
+	b := Vulnerability{Asset: "b.example.com", CheckType: "vulcan-tls", Vulnerability: vulcanreport.Vulnerability{Summary: "Weak TLS", Score: 6.9}}
+	rd := &ReportData{Date: "2022-10-12"}
+	rd.SetFindingDates(map[string]FindingDates{
+		FindingKey(a): {FirstSeen: "2022-10-05", LastSeen: "2022-10-12"},
+		FindingKey(b): {FirstSeen: "2022-10-01", LastSeen: "2022-10-12"},
+	}, config.SLAConfig{Medium: 10})
+
+	// A vulnerability grouping several findings is open since the oldest.
+	v := Vulnerability{Vulnerability: vulcanreport.Vulnerability{Summary: "Weak TLS", Score: 6.9}}
+	rd.SetLifecycle(&v, a, b)
+	if v.FirstSeen != "2022-10-01" || v.OpenDays != 11 || !v.SLABreached {
+		t.Errorf("unexpected lifecycle: first seen %s, %d days, SLA breached %v", v.FirstSeen, v.OpenDays, v.SLABreached)
+	}
+}
@@ -1,54 +1,88 @@
 package persistence
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
+
+	"github.com/adevinta/security-overview/vulcan/client"
+)
+
+var (
+	// ErrScanNotFound is returned when the scan does not exist.
+	ErrScanNotFound = errors.New("scan not found")
+	// ErrUnauthorized is matched by the errors returned when
+	// vulcan-persistence rejects the credentials of the request.
+	ErrUnauthorized = client.ErrUnauthorized
+	// ErrUpstream is matched by the errors returned when vulcan-persistence
+	// responds with an unexpected status code. Use errors.As with a
+	// *client.StatusError to get the status code and the body.
+	ErrUpstream = client.ErrUpstream
 )
 
-// GetDate retrieves the date for a scan.
-func GetDate(baseEndpoint, scanID string) (string, error) {
+// GetDate retrieves the date for a scan. The requests are sent using the
+// given HTTP client or, if it is nil, using http.DefaultClient.
+func GetDate(ctx context.Context, c *http.Client, baseEndpoint, scanID string) (string, error) {
 	url := baseEndpoint + "/v1/scans/" + scanID
-	resp, err := http.Get(url)
-	if err != nil {
-		return "", errors.New("Error calling endpoint: " + url + "\n" + err.Error())
+	body, _, err := get(ctx, c, url)
+	if errors.Is(err, client.ErrNotFound) {
+		return "", fmt.Errorf("%w: %s", ErrScanNotFound, scanID)
 	}
-	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", errors.New("Error calling endpoint: " + url + "\n" + err.Error())
+		return "", err
 	}
 	scan := &Scan{}
 	err = json.Unmarshal(body, scan)
 	if err != nil {
-		return "", errors.New("Error calling endpoint: " + url + "\n" + err.Error())
+		return "", fmt.Errorf("Error calling endpoint: %s\n%w", url, err)
+	}
+	// An empty start time means the scan does not exist.
+	if scan.StartTime.IsZero() {
+		return "", fmt.Errorf("%w: %s", ErrScanNotFound, scanID)
 	}
 
 	date := scan.StartTime.Format("2006-01-02")
 	return date, nil
 }
 
-// GetChecks retrieves all checks for a scan.
-func GetChecks(baseEndpoint, scanID string) ([]Check, error) {
-	url := baseEndpoint + "/v1/scans/" + scanID + "/checks?status=FINISHED"
-	resp, err := http.Get(url)
+// GetChecks retrieves all checks for a scan, regardless of their status. For large scans prefer
+// NewChecksIterator, that streams the checks as the pages are retrieved.
+func GetChecks(ctx context.Context, c *http.Client, baseEndpoint, scanID string) ([]Check, error) {
+	checks := []Check{}
+	it := NewChecksIterator(c, baseEndpoint, scanID, DefaultPageSize)
+	for it.Next(ctx) {
+		checks = append(checks, it.Check())
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	return checks, nil
+}
+
+func get(ctx context.Context, c *http.Client, url string) ([]byte, http.Header, error) {
+	if c == nil {
+		c = http.DefaultClient
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error calling endpoint: %s\n%w", url, err)
+	}
+	resp, err := c.Do(req)
 	if err != nil {
-		return nil, errors.New("Error calling endpoint: " + url + "\n" + err.Error())
+		return nil, nil, fmt.Errorf("Error calling endpoint: %s\n%w", url, err)
 	}
 	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, errors.New("Error calling endpoint: " + url + "\n" + err.Error())
+	if err := client.CheckResponse(resp); err != nil {
+		return nil, nil, err
 	}
 
-	checksResp := &Checks{}
-	err = json.Unmarshal(body, checksResp)
+	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, errors.New("Error calling endpoint: " + url + "\n" + err.Error())
+		return nil, nil, fmt.Errorf("Error calling endpoint: %s\n%w", url, err)
 	}
-
-	checks := checksResp.Checks
-	return checks, nil
+	return body, resp.Header, nil
 }
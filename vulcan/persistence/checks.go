@@ -0,0 +1,173 @@
+package persistence
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/adevinta/security-overview/vulcan/client"
+)
+
+// DefaultPageSize is the number of checks requested per page when no other
+// size is specified.
+const DefaultPageSize = 1000
+
+// ChecksIterator iterates over the checks of a scan, in any status. The pages of
+// checks are requested to vulcan-persistence as they are needed, so the
+// checks can be processed before the whole list is retrieved.
+//
+// The next page is found, in order of preference, from the "next" link in
+// the Link header of the response, from the pagination object of the body or,
+// when the API does not return pagination information, by requesting the
+// following page while the pages are full.
+type ChecksIterator struct {
+	client       *http.Client
+	baseEndpoint string
+	scanID       string
+	size         int
+
+	next     string
+	page     int
+	checks   []Check
+	current  Check
+	firstID  string
+	total    int
+	finished bool
+	err      error
+}
+
+// NewChecksIterator returns an iterator over the checks of a scan
+// that requests pages of the given size using the given HTTP client or, if it
+// is nil, using http.DefaultClient.
+func NewChecksIterator(c *http.Client, baseEndpoint, scanID string, size int) *ChecksIterator {
+	if size <= 0 {
+		size = DefaultPageSize
+	}
+	return &ChecksIterator{
+		client:       c,
+		baseEndpoint: baseEndpoint,
+		scanID:       scanID,
+		size:         size,
+		page:         1,
+		total:        -1,
+		next:         pageURL(baseEndpoint, scanID, 1, size),
+	}
+}
+
+// Next advances the iterator to the next check, requesting a new page if
+// needed. It returns false when there are no more checks or an error
+// happened, which is returned by Err.
+func (it *ChecksIterator) Next(ctx context.Context) bool {
+	for len(it.checks) == 0 {
+		if it.finished || it.err != nil {
+			return false
+		}
+		it.err = it.fetch(ctx)
+	}
+	it.current = it.checks[0]
+	it.checks = it.checks[1:]
+	return true
+}
+
+// Check returns the current check.
+func (it *ChecksIterator) Check() Check {
+	return it.current
+}
+
+// Err returns the error, if any, that stopped the iteration.
+func (it *ChecksIterator) Err() error {
+	return it.err
+}
+
+// Total returns the total number of checks of the scan, if it has been
+// returned by the API, or -1 otherwise.
+func (it *ChecksIterator) Total() int {
+	return it.total
+}
+
+func (it *ChecksIterator) fetch(ctx context.Context) error {
+	body, header, err := get(ctx, it.client, it.next)
+	if errors.Is(err, client.ErrNotFound) {
+		return fmt.Errorf("%w: %s", ErrScanNotFound, it.scanID)
+	}
+	if err != nil {
+		return err
+	}
+
+	checksResp := &Checks{}
+	err = json.Unmarshal(body, checksResp)
+	if err != nil {
+		return fmt.Errorf("Error calling endpoint: %s\n%w", it.next, err)
+	}
+
+	// APIs not supporting pagination return all the checks in every page.
+	if len(checksResp.Checks) > 0 {
+		if it.page > 1 && checksResp.Checks[0].ID == it.firstID {
+			it.finished = true
+			return nil
+		}
+		it.firstID = checksResp.Checks[0].ID
+	}
+	it.checks = checksResp.Checks
+	it.page++
+
+	if p := checksResp.Pagination; p != nil && p.Total > 0 {
+		it.total = p.Total
+	}
+
+	switch {
+	case header.Get("Link") != "":
+		next := nextLink(header)
+		if next == "" {
+			it.finished = true
+			break
+		}
+		u, err := url.Parse(it.next)
+		if err != nil {
+			return err
+		}
+		ref, err := url.Parse(next)
+		if err != nil {
+			return err
+		}
+		it.next = u.ResolveReference(ref).String()
+	case checksResp.Pagination != nil:
+		it.finished = !checksResp.Pagination.More
+		it.next = pageURL(it.baseEndpoint, it.scanID, it.page, it.size)
+	default:
+		it.finished = len(checksResp.Checks) != it.size
+		it.next = pageURL(it.baseEndpoint, it.scanID, it.page, it.size)
+	}
+
+	return nil
+}
+
+func pageURL(baseEndpoint, scanID string, page, size int) string {
+	return baseEndpoint + "/v1/scans/" + scanID + "/checks?page=" + strconv.Itoa(page) + "&size=" + strconv.Itoa(size)
+}
+
+// nextLink returns the URL of the "next" relation of the Link header, as
+// defined in RFC 8288, or an empty string if there is not such relation.
+func nextLink(header http.Header) string {
+	for _, value := range header.Values("Link") {
+		for _, link := range strings.Split(value, ",") {
+			parts := strings.Split(link, ";")
+			if len(parts) < 2 {
+				continue
+			}
+			target := strings.Trim(strings.TrimSpace(parts[0]), "<>")
+			for _, param := range parts[1:] {
+				param = strings.TrimSpace(param)
+				if param == `rel="next"` || param == "rel=next" {
+					return target
+				}
+			}
+		}
+	}
+	return ""
+}
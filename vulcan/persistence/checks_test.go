@@ -0,0 +1,146 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// page is the response of the test server to a request of checks.
+type page struct {
+	link string
+	body string
+}
+
+func TestChecksIterator(t *testing.T) {
+	tests := []struct {
+		name string
+		size int
+		// pages are indexed by the query of the request.
+		pages        map[string]page
+		wantIDs      []string
+		wantRequests int
+		wantTotal    int
+		wantErr      error
+	}{
+		{
+			name: "link header",
+			size: 2,
+			pages: map[string]page{
+				"page=1&size=2": {link: `</v1/scans/s/checks?cursor=c2>; rel="next"`, body: `{"checks":[{"id":"c1"},{"id":"c2"}]}`},
+				"cursor=c2":     {link: `</v1/scans/s/checks?page=1&size=2>; rel="first"`, body: `{"checks":[{"id":"c3"}]}`},
+			},
+			wantIDs:      []string{"c1", "c2", "c3"},
+			wantRequests: 2,
+			wantTotal:    -1,
+		},
+		{
+			name: "pagination object",
+			size: 2,
+			pages: map[string]page{
+				"page=1&size=2": {body: `{"checks":[{"id":"c1"},{"id":"c2"}],"pagination":{"page":1,"size":2,"total":3,"more":true}}`},
+				"page=2&size=2": {body: `{"checks":[{"id":"c3"}],"pagination":{"page":2,"size":2,"total":3,"more":false}}`},
+			},
+			wantIDs:      []string{"c1", "c2", "c3"},
+			wantRequests: 2,
+			wantTotal:    3,
+		},
+		{
+			name: "full pages",
+			size: 2,
+			pages: map[string]page{
+				"page=1&size=2": {body: `{"checks":[{"id":"c1"},{"id":"c2"}]}`},
+				"page=2&size=2": {body: `{"checks":[{"id":"c3"},{"id":"c4"}]}`},
+				"page=3&size=2": {body: `{"checks":[]}`},
+			},
+			wantIDs:      []string{"c1", "c2", "c3", "c4"},
+			wantRequests: 3,
+			wantTotal:    -1,
+		},
+		{
+			name: "pagination not supported",
+			size: 2,
+			pages: map[string]page{
+				"page=1&size=2": {body: `{"checks":[{"id":"c1"},{"id":"c2"}]}`},
+				"page=2&size=2": {body: `{"checks":[{"id":"c1"},{"id":"c2"}]}`},
+			},
+			wantIDs:      []string{"c1", "c2"},
+			wantRequests: 2,
+			wantTotal:    -1,
+		},
+		{
+			name:         "scan not found",
+			size:         2,
+			pages:        map[string]page{},
+			wantRequests: 1,
+			wantTotal:    -1,
+			wantErr:      ErrScanNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				p, ok := tt.pages[r.URL.RawQuery]
+				if !ok || r.URL.Path != "/v1/scans/s/checks" {
+					http.NotFound(w, r)
+					return
+				}
+				if p.link != "" {
+					w.Header().Set("Link", p.link)
+				}
+				w.Write([]byte(p.body))
+			}))
+			defer srv.Close()
+
+			it := NewChecksIterator(srv.Client(), srv.URL, "s", tt.size)
+			var ids []string
+			for it.Next(context.Background()) {
+				ids = append(ids, it.Check().ID)
+			}
+			if err := it.Err(); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("unexpected error: got %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(ids, tt.wantIDs) {
+				t.Errorf("unexpected checks: got %v, want %v", ids, tt.wantIDs)
+			}
+			if requests != tt.wantRequests {
+				t.Errorf("unexpected number of requests: got %d, want %d", requests, tt.wantRequests)
+			}
+			if got := it.Total(); got != tt.wantTotal {
+				t.Errorf("unexpected total: got %d, want %d", got, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestNextLink(t *testing.T) {
+	tests := []struct {
+		name  string
+		links []string
+		want  string
+	}{
+		{name: "no links"},
+		{name: "next", links: []string{`<http://vulcan/next>; rel="next"`}, want: "http://vulcan/next"},
+		{name: "unquoted", links: []string{`<http://vulcan/next>; rel=next`}, want: "http://vulcan/next"},
+		{name: "several links", links: []string{`<http://vulcan/prev>; rel="prev", <http://vulcan/next>; rel="next"`}, want: "http://vulcan/next"},
+		{name: "several headers", links: []string{`<http://vulcan/prev>; rel="prev"`, `<http://vulcan/next>; rel="next"`}, want: "http://vulcan/next"},
+		{name: "no next", links: []string{`<http://vulcan/prev>; rel="prev"`}},
+		{name: "invalid", links: []string{`http://vulcan/next`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			for _, l := range tt.links {
+				header.Add("Link", l)
+			}
+			if got := nextLink(header); got != tt.want {
+				t.Errorf("unexpected link: got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -12,7 +12,16 @@ type Scan struct {
 
 //Checks represents the response from /scan/{id}/checks
 type Checks struct {
-	Checks []Check `json:"checks"`
+	Checks     []Check     `json:"checks"`
+	Pagination *Pagination `json:"pagination,omitempty"`
+}
+
+//Pagination represents the pagination information of a page of Checks
+type Pagination struct {
+	Page  int  `json:"page"`
+	Size  int  `json:"size"`
+	Total int  `json:"total"`
+	More  bool `json:"more"`
 }
 
 //Check represents an individual check from Checks
@@ -22,4 +31,5 @@ type Check struct {
 	Status        string `json:"status"`
 	Report        string `json:"report"`
 	CheckTypeName string `json:"checktype_name"`
+	AssetType     string `json:"assettype,omitempty"`
 }
@@ -0,0 +1,224 @@
+package vulcan
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	vulcanreport "github.com/adevinta/vulcan-report"
+)
+
+// Policy contains the rules adjusting the score of the findings to the
+// context of the assets, as described by their metadata in the inventory. The
+// first rule matching a finding is applied.
+type Policy struct {
+	Overrides []OverrideRule `toml:"override"`
+}
+
+// OverrideRule defines the new score of the findings matching it. Exactly one
+// of Severity, Score and Adjust must be defined.
+type OverrideRule struct {
+	FindingFilter
+	// Tag matches the assets with the given tag in the inventory.
+	Tag string `toml:"tag" json:"tag,omitempty"`
+	// Environment matches the assets of the given environment in the
+	// inventory.
+	Environment string `toml:"environment" json:"environment,omitempty"`
+	// Severity sets the score to the maximum score of the severity: info,
+	// low, medium, high or critical.
+	Severity string `toml:"severity" json:"severity,omitempty"`
+	// Score sets the score.
+	Score *float32 `toml:"score" json:"score,omitempty"`
+	// Adjust is added to the score, which is kept between 0 and 10.
+	Adjust float32 `toml:"adjust" json:"adjust,omitempty"`
+	Reason string  `toml:"reason" json:"reason"`
+	Owner  string  `toml:"owner" json:"owner"`
+}
+
+// ScoreOverride records the change of the score of a finding by a rule of the
+// policy.
+type ScoreOverride struct {
+	OriginalScore float32      `json:"original_score"`
+	Score         float32      `json:"score"`
+	Rule          OverrideRule `json:"rule"`
+}
+
+// OverriddenFinding is a finding whose score has been overridden, as recorded
+// in the audit trail of the report data.
+type OverriddenFinding struct {
+	Asset     string        `json:"asset"`
+	CheckType string        `json:"checktype"`
+	Summary   string        `json:"summary"`
+	Override  ScoreOverride `json:"override"`
+}
+
+// ReadPolicy reads the policy defined in a TOML file like the following:
+//
+//	[[override]]
+//	tag = "internal"
+//	checktype = "vulcan-exposed-http-resources"
+//	severity = "low"
+//	reason = "Only reachable from the corporate network"
+//	owner = "jane@example.com"
+//
+//	[[override]]
+//	environment = "production"
+//	summary = "^Weak SSL/TLS"
+//	adjust = 1.5
+//	reason = "Handles customer data"
+//	owner = "jane@example.com"
+//
+// The tags and the environments are the ones of the assets in the inventory.
+func ReadPolicy(file string) (*Policy, error) {
+	p := &Policy{}
+	if _, err := toml.DecodeFile(file, p); err != nil {
+		return nil, err
+	}
+	for i := range p.Overrides {
+		if err := p.Overrides[i].compile(); err != nil {
+			return nil, fmt.Errorf("invalid override rule %d: %w", i+1, err)
+		}
+	}
+	return p, nil
+}
+
+func (r *OverrideRule) compile() error {
+	// The rules matching the assets by their metadata do not need to match
+	// the findings by other criteria.
+	if (r.Tag == "" && r.Environment == "") || r.Asset != "" || r.CheckType != "" || r.Summary != "" || r.Fingerprint != "" {
+		if err := r.FindingFilter.compile(); err != nil {
+			return err
+		}
+	}
+	if r.Reason == "" || r.Owner == "" {
+		return errors.New("reason and owner are required")
+	}
+
+	actions := 0
+	if r.Severity != "" {
+		if _, ok := parseSeverity(r.Severity); !ok {
+			return fmt.Errorf("unknown severity: %s", r.Severity)
+		}
+		actions++
+	}
+	if r.Score != nil {
+		if *r.Score < 0 || *r.Score > 10 {
+			return fmt.Errorf("invalid score: %v", *r.Score)
+		}
+		actions++
+	}
+	if r.Adjust != 0 {
+		actions++
+	}
+	if actions != 1 {
+		return errors.New("exactly one of severity, score and adjust is required")
+	}
+	return nil
+}
+
+// matchAsset returns true if the metadata of the asset has the tag and the
+// environment of the rule, if defined.
+func (r OverrideRule) matchAsset(md AssetMetadata) bool {
+	if r.Environment != "" && r.Environment != md.Environment {
+		return false
+	}
+	if r.Tag == "" {
+		return true
+	}
+	for _, tag := range md.Tags {
+		if tag == r.Tag {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply overrides the score of the finding with the first rule matching it
+// and the metadata of its asset, if any. The score of the vulnerabilities it
+// contains is lowered to not exceed the new score.
+func (p *Policy) Apply(v *Vulnerability, md AssetMetadata) (ScoreOverride, bool) {
+	if p == nil {
+		return ScoreOverride{}, false
+	}
+	for _, r := range p.Overrides {
+		if !r.matchAsset(md) || !r.FindingFilter.match(*v) {
+			continue
+		}
+
+		o := ScoreOverride{OriginalScore: v.Vulnerability.Score, Score: r.score(v.Vulnerability.Score), Rule: r}
+		v.Vulnerability.Score = o.Score
+		if len(v.Vulnerability.Vulnerabilities) > 0 {
+			// The vulnerabilities are copied to not modify the ones of the
+			// report of the check.
+			vulns := make([]vulcanreport.Vulnerability, len(v.Vulnerability.Vulnerabilities))
+			copy(vulns, v.Vulnerability.Vulnerabilities)
+			for i := range vulns {
+				if vulns[i].Score > o.Score {
+					vulns[i].Score = o.Score
+				}
+			}
+			v.Vulnerability.Vulnerabilities = vulns
+		}
+		return o, true
+	}
+	return ScoreOverride{}, false
+}
+
+// SetOverride sets in a vulnerability the override of the first of the given
+// findings whose score has been overridden, so the vulnerabilities grouping
+// several findings show it.
+func (rp *ReportData) SetOverride(v *Vulnerability, findings ...Vulnerability) {
+	for _, f := range findings {
+		if o, ok := rp.overrides[FindingKey(f)]; ok {
+			v.Override = o
+			return
+		}
+	}
+}
+
+func (r OverrideRule) score(score float32) float32 {
+	switch {
+	case r.Severity != "":
+		severity, _ := parseSeverity(r.Severity)
+		return vulcanreport.ScoreSeverity(severity)
+	case r.Score != nil:
+		return *r.Score
+	}
+	score += r.Adjust
+	if score < 0 {
+		return 0
+	}
+	if score > 10 {
+		return 10
+	}
+	return score
+}
+
+func parseSeverity(s string) (vulcanreport.SeverityRank, bool) {
+	for _, severity := range []vulcanreport.SeverityRank{
+		vulcanreport.SeverityNone,
+		vulcanreport.SeverityLow,
+		vulcanreport.SeverityMedium,
+		vulcanreport.SeverityHigh,
+		vulcanreport.SeverityCritical,
+	} {
+		if strings.EqualFold(s, severityToString(severity)) {
+			return severity, true
+		}
+	}
+	return vulcanreport.SeverityNone, false
+}
+
+func sortOverridden(findings []OverriddenFinding) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Asset == findings[j].Asset {
+			if findings[i].CheckType == findings[j].CheckType {
+				return findings[i].Summary < findings[j].Summary
+			}
+			return findings[i].CheckType < findings[j].CheckType
+		}
+		return findings[i].Asset < findings[j].Asset
+	})
+}
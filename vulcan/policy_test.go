@@ -0,0 +1,299 @@
+package vulcan
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/adevinta/security-overview/config"
+	"github.com/adevinta/vulcan-groupie/db"
+	"github.com/adevinta/vulcan-groupie/pkg/groupie"
+	vulcanreport "github.com/adevinta/vulcan-report"
+)
+
+const testPolicy = `
+[[override]]
+tag = "internal"
+checktype = "vulcan-exposed-http"
+severity = "low"
+reason = "Only reachable from the corporate network"
+owner = "jane@example.com"
+
+[[override]]
+summary = "^Outdated"
+adjust = -2.5
+reason = "Mitigated by the WAF"
+owner = "jane@example.com"
+
+[[override]]
+fingerprint = "abc"
+score = 9.5
+reason = "Exploited in the wild"
+owner = "jane@example.com"
+
+[[override]]
+asset = "*.example.com"
+adjust = 5
+reason = "Critical business"
+owner = "jane@example.com"
+
+[[override]]
+environment = "staging"
+severity = "info"
+reason = "Not reachable from the Internet"
+owner = "jane@example.com"
+`
+
+func TestReadPolicy(t *testing.T) {
+	const rule = "reason = \"r\"\nowner = \"o\"\n"
+
+	tests := []struct {
+		name    string
+		content string
+		wantErr bool
+	}{
+		{name: "valid", content: testPolicy},
+		{name: "tag only", content: "[[override]]\ntag = \"internal\"\nseverity = \"info\"\n" + rule},
+		{name: "environment only", content: "[[override]]\nenvironment = \"staging\"\nseverity = \"info\"\n" + rule},
+		{name: "invalid asset glob", content: "[[override]]\nasset = \"[\"\nseverity = \"info\"\n" + rule, wantErr: true},
+		{name: "no criteria", content: "[[override]]\nseverity = \"low\"\n" + rule, wantErr: true},
+		{name: "no reason", content: "[[override]]\nchecktype = \"vulcan-tls\"\nseverity = \"low\"\nowner = \"o\"\n", wantErr: true},
+		{name: "unknown severity", content: "[[override]]\nchecktype = \"vulcan-tls\"\nseverity = \"urgent\"\n" + rule, wantErr: true},
+		{name: "invalid score", content: "[[override]]\nchecktype = \"vulcan-tls\"\nscore = 11.0\n" + rule, wantErr: true},
+		{name: "no action", content: "[[override]]\nchecktype = \"vulcan-tls\"\n" + rule, wantErr: true},
+		{name: "several actions", content: "[[override]]\nchecktype = \"vulcan-tls\"\nseverity = \"low\"\nadjust = 1.0\n" + rule, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, map[string]string{"policy.toml": tt.content})
+			_, err := ReadPolicy(filepath.Join(dir, "policy.toml"))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestPolicyApply(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"policy.toml": testPolicy})
+	p, err := ReadPolicy(filepath.Join(dir, "policy.toml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		v          Vulnerability
+		md         AssetMetadata
+		wantOK     bool
+		wantScore  float32
+		wantReason string
+	}{
+		{
+			name:       "tag and severity",
+			v:          Vulnerability{Asset: "app.corp.example.com", CheckType: "vulcan-exposed-http", Vulnerability: vulcanreport.Vulnerability{Summary: "Exposed file", Score: 8.9}},
+			md:         AssetMetadata{Asset: "app.corp.example.com", Tags: []string{"pci", "internal"}},
+			wantOK:     true,
+			wantScore:  vulcanreport.ScoreSeverity(vulcanreport.SeverityLow),
+			wantReason: "Only reachable from the corporate network",
+		},
+		{
+			name:      "tag of another check",
+			v:         Vulnerability{Asset: "10.0.0.1", CheckType: "vulcan-nmap", Vulnerability: vulcanreport.Vulnerability{Summary: "Open port", Score: 3.9}},
+			md:        AssetMetadata{Asset: "10.0.0.1", Tags: []string{"internal"}},
+			wantOK:    false,
+			wantScore: 3.9,
+		},
+		{
+			name:       "environment",
+			v:          Vulnerability{Asset: "10.0.0.1", CheckType: "vulcan-nmap", Vulnerability: vulcanreport.Vulnerability{Summary: "Open port", Score: 3.9}},
+			md:         AssetMetadata{Asset: "10.0.0.1", Environment: "staging"},
+			wantOK:     true,
+			wantScore:  vulcanreport.ScoreSeverity(vulcanreport.SeverityNone),
+			wantReason: "Not reachable from the Internet",
+		},
+		{
+			name:       "adjust",
+			v:          Vulnerability{Asset: "example.org", CheckType: "vulcan-trivy", Vulnerability: vulcanreport.Vulnerability{Summary: "Outdated packages", Score: 6.5}},
+			wantOK:     true,
+			wantScore:  4,
+			wantReason: "Mitigated by the WAF",
+		},
+		{
+			name:       "adjust under zero",
+			v:          Vulnerability{Asset: "example.org", CheckType: "vulcan-trivy", Vulnerability: vulcanreport.Vulnerability{Summary: "Outdated packages", Score: 1}},
+			wantOK:     true,
+			wantScore:  0,
+			wantReason: "Mitigated by the WAF",
+		},
+		{
+			name:       "score",
+			v:          Vulnerability{Asset: "example.org", CheckType: "vulcan-tls", Vulnerability: vulcanreport.Vulnerability{Summary: "Weak TLS", Score: 3.9, Fingerprint: "abc"}},
+			wantOK:     true,
+			wantScore:  9.5,
+			wantReason: "Exploited in the wild",
+		},
+		{
+			name:       "adjust over ten",
+			v:          Vulnerability{Asset: "www.example.com", CheckType: "vulcan-tls", Vulnerability: vulcanreport.Vulnerability{Summary: "Weak TLS", Score: 6.9}},
+			wantOK:     true,
+			wantScore:  10,
+			wantReason: "Critical business",
+		},
+		{
+			name:      "first rule",
+			v:         Vulnerability{Asset: "www.example.com", CheckType: "vulcan-trivy", Vulnerability: vulcanreport.Vulnerability{Summary: "Outdated packages", Score: 6.5}},
+			wantOK:    true,
+			wantScore: 4, wantReason: "Mitigated by the WAF",
+		},
+		{
+			name:      "not tagged",
+			v:         Vulnerability{Asset: "example.org", CheckType: "vulcan-exposed-http", Vulnerability: vulcanreport.Vulnerability{Summary: "Exposed file", Score: 8.9}},
+			md:        AssetMetadata{Asset: "example.org", Environment: "production", Tags: []string{"pci"}},
+			wantOK:    false,
+			wantScore: 8.9,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := tt.v
+			o, ok := p.Apply(&v, tt.md)
+			if ok != tt.wantOK {
+				t.Fatalf("unexpected result: got %v, want %v", ok, tt.wantOK)
+			}
+			if v.Vulnerability.Score != tt.wantScore {
+				t.Errorf("unexpected score: got %v, want %v", v.Vulnerability.Score, tt.wantScore)
+			}
+			if !ok {
+				return
+			}
+			if o.OriginalScore != tt.v.Vulnerability.Score || o.Score != tt.wantScore {
+				t.Errorf("unexpected override: %+v", o)
+			}
+			if o.Rule.Reason != tt.wantReason {
+				t.Errorf("unexpected rule: got %q, want %q", o.Rule.Reason, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestPolicyApplyVulnerabilities(t *testing.T) {
+	p := &Policy{Overrides: []OverrideRule{{
+		FindingFilter: FindingFilter{CheckType: "vulcan-trivy"},
+		Severity:      "medium",
+		Reason:        "Not exposed",
+		Owner:         "jane@example.com",
+	}}}
+	if err := p.Overrides[0].compile(); err != nil {
+		t.Fatal(err)
+	}
+	vulns := []vulcanreport.Vulnerability{{Summary: "CVE-1", Score: 9.8}, {Summary: "CVE-2", Score: 4.0}}
+	v := Vulnerability{
+		Asset:         "registry.example.com/app:1.0",
+		CheckType:     "vulcan-trivy",
+		Vulnerability: vulcanreport.Vulnerability{Summary: "Outdated packages", Score: 9.8, Vulnerabilities: vulns},
+	}
+	if _, ok := p.Apply(&v, AssetMetadata{}); !ok {
+		t.Fatal("policy not applied")
+	}
+
+	// The nested vulnerabilities do not exceed the new score and the ones
+	// of the report are not modified.
+	max := vulcanreport.ScoreSeverity(vulcanreport.SeverityMedium)
+	want := []float32{max, 4.0}
+	for i, nested := range v.Vulnerability.Vulnerabilities {
+		if nested.Score != want[i] {
+			t.Errorf("unexpected score of %s: got %v, want %v", nested.Summary, nested.Score, want[i])
+		}
+	}
+	if vulns[0].Score != 9.8 {
+		t.Errorf("vulnerabilities of the report modified: %+v", vulns)
+	}
+
+	var none *Policy
+	if _, ok := none.Apply(&v, AssetMetadata{}); ok {
+		t.Error("unexpected override without policy")
+	}
+}
+
+func TestAggregatorOverrides(t *testing.T) {
+	score := float32(2)
+	p := &Policy{Overrides: []OverrideRule{{
+		FindingFilter: FindingFilter{CheckType: "vulcan-tls"},
+		Score:         &score,
+		Reason:        "Required by legacy clients",
+		Owner:         "jane@example.com",
+	}}}
+	if err := p.Overrides[0].compile(); err != nil {
+		t.Fatal(err)
+	}
+	a := newAggregator("scan", "2022-10-12", groupie.New(db.NewMemDB()), nil, p, nil)
+	// The check is run twice against the asset.
+	for i := 0; i < 2; i++ {
+		a.add(context.Background(), newTestReport("example.com", "vulcan-tls", vulcanreport.Vulnerability{Summary: "Weak TLS", Score: 6.9}), AssetTypeHostname)
+	}
+	a.add(context.Background(), newTestReport("example.com", "vulcan-nmap", vulcanreport.Vulnerability{Summary: "Open port", Score: 3.9}), AssetTypeHostname)
+	rd := &ReportData{}
+	if err := a.apply(rd); err != nil {
+		t.Fatal(err)
+	}
+
+	// The overridden findings are recorded once in the audit trail and the
+	// risk uses the new score.
+	if len(rd.Overrides) != 1 {
+		t.Fatalf("unexpected overrides: %+v", rd.Overrides)
+	}
+	o := rd.Overrides[0]
+	if o.Asset != "example.com" || o.Summary != "Weak TLS" || o.Override.OriginalScore != 6.9 || o.Override.Score != 2 {
+		t.Errorf("unexpected override: %+v", o)
+	}
+	if rd.Risk != vulcanreport.SeverityLow {
+		t.Errorf("unexpected risk: got %v, want %v", rd.Risk, vulcanreport.SeverityLow)
+	}
+}
+
+func TestGetReportDataFromSourceInventoryPolicy(t *testing.T) {
+	p := &Policy{Overrides: []OverrideRule{{
+		Tag:      "internal",
+		Severity: "info",
+		Reason:   "Only reachable from the corporate network",
+		Owner:    "jane@example.com",
+	}}}
+	if err := p.Overrides[0].compile(); err != nil {
+		t.Fatal(err)
+	}
+	inv := MapInventory{
+		"a.example.com": {Asset: "a.example.com", Environment: "production", Tags: []string{"internal"}},
+		"b.example.com": {Asset: "b.example.com", Environment: "production", Tags: []string{"pci"}},
+	}
+	conf := config.Config{}
+	conf.Results.Workers = 1
+	source := newMemSource([]string{"a.example.com", "b.example.com", "c.example.com"})
+	rd, err := GetReportDataFromSource(context.Background(), conf, source, "scan", Options{Policy: p, Inventory: inv})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the findings of the asset tagged in the inventory are overridden.
+	if len(rd.Overrides) != 1 || rd.Overrides[0].Asset != "a.example.com" {
+		t.Fatalf("unexpected overrides: %+v", rd.Overrides)
+	}
+	for _, v := range rd.Vulnerabilities {
+		want := float32(6.9)
+		if v.Asset == "a.example.com" {
+			want = vulcanreport.ScoreSeverity(vulcanreport.SeverityNone)
+		}
+		if v.Vulnerability.Score != want {
+			t.Errorf("unexpected score of %s: got %v, want %v", v.Asset, v.Vulnerability.Score, want)
+		}
+	}
+	// The report data keeps the metadata the policy was applied with.
+	if got := rd.AssetsMetadata["a.example.com"].Tags; len(got) != 1 || got[0] != "internal" {
+		t.Errorf("unexpected tags: %v", got)
+	}
+	if got := rd.AssetsMetadata["c.example.com"]; got.Environment != "" || len(got.Tags) != 0 {
+		t.Errorf("unexpected metadata of an asset not in the inventory: %+v", got)
+	}
+}
@@ -0,0 +1,186 @@
+package vulcan
+
+import (
+	"context"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/adevinta/security-overview/vulcan/persistence"
+	vulcanreport "github.com/adevinta/vulcan-report"
+)
+
+const (
+	// ErrorPolicyCollectAll makes the collection go on when the report of a
+	// check can not be retrieved. The check is reported as missing.
+	ErrorPolicyCollectAll = "collect-all"
+	// ErrorPolicyFirstError makes the collection fail as soon as the report
+	// of a check can not be retrieved.
+	ErrorPolicyFirstError = "first-error"
+)
+
+// Progress describes the state of the collection of the reports of a scan.
+type Progress struct {
+	// Done is the number of checks processed, including the failed ones.
+	Done int
+	// Failed is the number of checks whose report could not be retrieved.
+	Failed int
+	// Total is the number of checks of the scan, or -1 if it is not known
+	// yet.
+	Total   int
+	Elapsed time.Duration
+	// ETA is the estimated time left, or zero if it can not be estimated.
+	ETA time.Duration
+}
+
+// ProgressFunc is called every time a check is processed. It is never called
+// concurrently.
+type ProgressFunc func(Progress)
+
+// logProgress is the ProgressFunc used when none is given.
+func logProgress(p Progress) {
+	if p.Done%100 == 0 {
+		log.Printf("%d checks processed", p.Done)
+	}
+}
+
+// progressTracker keeps the state needed to report the progress of a
+// collection.
+type progressTracker struct {
+	f     ProgressFunc
+	start time.Time
+	total atomic.Int64
+	done  int
+}
+
+func newProgressTracker(f ProgressFunc) *progressTracker {
+	t := &progressTracker{f: f, start: time.Now()}
+	t.total.Store(-1)
+	return t
+}
+
+// setTotal sets the number of checks of the scan. It can be called
+// concurrently with update.
+func (t *progressTracker) setTotal(total int) {
+	t.total.Store(int64(total))
+}
+
+// totalChecks returns the number of checks of the scan, or -1 if it is not
+// known yet.
+func (t *progressTracker) totalChecks() int {
+	return int(t.total.Load())
+}
+
+// update reports that a check has been processed.
+func (t *progressTracker) update(failed int) {
+	t.done++
+	p := Progress{
+		Done:    t.done,
+		Failed:  failed,
+		Total:   t.totalChecks(),
+		Elapsed: time.Since(t.start),
+	}
+	if p.Total > p.Done {
+		p.ETA = p.Elapsed * time.Duration(p.Total-p.Done) / time.Duration(p.Done)
+	}
+	t.f(p)
+}
+
+// totaler is implemented by the CheckIterators that know the number of checks
+// of the scan before iterating over all of them.
+type totaler interface {
+	// Total returns the number of checks, or a negative number if it is not
+	// known.
+	Total() int
+}
+
+// checkResult is the outcome of processing a check. The report is nil if the
+// check did not finish, in which case reason explains why, if known.
+type checkResult struct {
+	check  persistence.Check
+	report *vulcanreport.Report
+	reason string
+	err    error
+}
+
+// group is a collection of goroutines working on subtasks of the same task.
+// The first goroutine returning an error cancels the context of the group,
+// and that error is the one returned by Wait.
+type group struct {
+	wg      sync.WaitGroup
+	cancel  context.CancelFunc
+	errOnce sync.Once
+	err     error
+}
+
+// withGroup returns a new group and a context derived from ctx that is
+// canceled when a goroutine of the group fails or Wait returns.
+func withGroup(ctx context.Context) (*group, context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	return &group{cancel: cancel}, ctx
+}
+
+// Go calls f in a new goroutine.
+func (g *group) Go(f func() error) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		if err := f(); err != nil {
+			g.errOnce.Do(func() {
+				g.err = err
+				g.cancel()
+			})
+		}
+	}()
+}
+
+// Wait blocks until all the goroutines of the group return, and returns the
+// first error, if any.
+func (g *group) Wait() error {
+	g.wg.Wait()
+	g.cancel()
+	return g.err
+}
+
+// rateLimiter limits the rate of the requests to a number of requests per
+// second.
+type rateLimiter struct {
+	ticker *time.Ticker
+}
+
+func newRateLimiter(rps float64) *rateLimiter {
+	interval := time.Duration(float64(time.Second) / rps)
+	if interval <= 0 {
+		interval = 1
+	}
+	return &rateLimiter{ticker: time.NewTicker(interval)}
+}
+
+// wait blocks until a request can be sent or the context is done.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	select {
+	case <-l.ticker.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (l *rateLimiter) stop() {
+	l.ticker.Stop()
+}
+
+// rateLimitedSource is a ReportSource whose requests of reports are rate
+// limited.
+type rateLimitedSource struct {
+	ReportSource
+	limiter *rateLimiter
+}
+
+func (s *rateLimitedSource) Report(ctx context.Context, check persistence.Check) (*vulcanreport.Report, error) {
+	if err := s.limiter.wait(ctx); err != nil {
+		return nil, err
+	}
+	return s.ReportSource.Report(ctx, check)
+}
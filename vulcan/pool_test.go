@@ -0,0 +1,148 @@
+package vulcan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/adevinta/security-overview/config"
+	"github.com/adevinta/security-overview/vulcan/persistence"
+	vulcanreport "github.com/adevinta/vulcan-report"
+)
+
+// memSource is a ReportSource serving the reports of a scan from memory. The
+// checks without report fail with errReportNotFound.
+type memSource struct {
+	date    string
+	checks  []persistence.Check
+	reports map[string]*vulcanreport.Report
+}
+
+var errReportNotFound = errors.New("report not found")
+
+func (s *memSource) ScanDate(ctx context.Context, scanID string) (string, error) {
+	return s.date, nil
+}
+
+func (s *memSource) Checks(scanID string) CheckIterator {
+	return &sliceIterator{checks: s.checks, total: len(s.checks)}
+}
+
+func (s *memSource) Report(ctx context.Context, check persistence.Check) (*vulcanreport.Report, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r, ok := s.reports[check.ID]
+	if !ok {
+		return nil, errReportNotFound
+	}
+	return r, nil
+}
+
+// newMemSource returns a source with a finished check per target. The
+// reports of the failed targets are missing.
+func newMemSource(targets []string, failed ...string) *memSource {
+	s := &memSource{date: "2022-10-12", reports: make(map[string]*vulcanreport.Report)}
+	missing := make(map[string]bool)
+	for _, target := range failed {
+		missing[target] = true
+	}
+	for i, target := range targets {
+		id := fmt.Sprintf("c%d", i)
+		s.checks = append(s.checks, persistence.Check{ID: id, Target: target, Status: StatusFinished, CheckTypeName: "vulcan-tls"})
+		if !missing[target] {
+			r := newTestReport(target, "vulcan-tls", vulcanreport.Vulnerability{Summary: "Weak TLS", Score: 6.9})
+			s.reports[id] = r
+		}
+	}
+	return s
+}
+
+func TestGetReportDataFromSourceErrorPolicy(t *testing.T) {
+	targets := []string{"a.example.com", "b.example.com", "c.example.com", "d.example.com"}
+
+	tests := []struct {
+		name        string
+		policy      string
+		failed      []string
+		wantErr     error
+		wantMissing []string
+		wantAssets  int
+	}{
+		{name: "collect-all without errors", policy: ErrorPolicyCollectAll, wantMissing: []string{}, wantAssets: 4},
+		{name: "default policy", policy: "", failed: []string{"b.example.com"}, wantMissing: []string{"b.example.com"}, wantAssets: 3},
+		{name: "collect-all", policy: ErrorPolicyCollectAll, failed: []string{"a.example.com", "c.example.com"}, wantMissing: []string{"a.example.com", "c.example.com"}, wantAssets: 2},
+		{name: "first-error without errors", policy: ErrorPolicyFirstError, wantMissing: []string{}, wantAssets: 4},
+		{name: "first-error", policy: ErrorPolicyFirstError, failed: []string{"c.example.com"}, wantErr: errReportNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := config.Config{}
+			conf.Results.Workers = 2
+			conf.Results.ErrorPolicy = tt.policy
+			var progress []Progress
+			opts := Options{Progress: func(p Progress) { progress = append(progress, p) }}
+
+			rd, err := GetReportDataFromSource(context.Background(), conf, newMemSource(targets, tt.failed...), "scan", opts)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("unexpected error: got %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			missing := []string{}
+			for _, m := range rd.MissingChecks {
+				missing = append(missing, m.Target)
+			}
+			sort.Strings(missing)
+			if fmt.Sprint(missing) != fmt.Sprint(tt.wantMissing) {
+				t.Errorf("unexpected missing checks: got %v, want %v", missing, tt.wantMissing)
+			}
+			if got := rd.NumberOfVulnerableAssets; got != tt.wantAssets {
+				t.Errorf("unexpected vulnerable assets: got %d, want %d", got, tt.wantAssets)
+			}
+			// Every check is reported as processed, including the failed
+			// ones.
+			if len(progress) != len(targets) {
+				t.Fatalf("unexpected progress updates: got %d, want %d", len(progress), len(targets))
+			}
+			last := progress[len(progress)-1]
+			if last.Done != len(targets) || last.Total != len(targets) || last.Failed != len(tt.failed) {
+				t.Errorf("unexpected progress: %+v", last)
+			}
+		})
+	}
+}
+
+func TestGetReportDataFromSourceInvalidPolicy(t *testing.T) {
+	conf := config.Config{}
+	conf.Results.Workers = 1
+	conf.Results.ErrorPolicy = "unknown"
+	if _, err := GetReportDataFromSource(context.Background(), conf, newMemSource(nil), "scan", Options{}); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestGetReportDataFromSourceCanceled(t *testing.T) {
+	conf := config.Config{}
+	conf.Results.Workers = 1
+	ctx, cancel := context.WithCancel(context.Background())
+	// The collection is canceled after the first check is processed.
+	opts := Options{Progress: func(p Progress) { cancel() }}
+
+	_, err := GetReportDataFromSource(ctx, conf, newMemSource([]string{"a.example.com", "b.example.com", "c.example.com"}), "scan", opts)
+	var partial *PartialCollectionError
+	if !errors.As(err, &partial) {
+		t.Fatalf("unexpected error: got %v, want a *PartialCollectionError", err)
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("unexpected cause: %v", partial.Err)
+	}
+	// The reports fetched before the cancellation are still collected.
+	if partial.Collected < 1 || partial.Collected > 3 {
+		t.Errorf("unexpected number of collected reports: %d", partial.Collected)
+	}
+}
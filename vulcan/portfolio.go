@@ -0,0 +1,174 @@
+package vulcan
+
+import (
+	"sort"
+
+	vulcanreport "github.com/adevinta/vulcan-report"
+)
+
+// TeamReportData is the report data of the scan of a team included in a
+// portfolio.
+type TeamReportData struct {
+	TeamID   string
+	TeamName string
+	Data     *ReportData
+}
+
+// Portfolio contains the data of a report merging the scans of several teams.
+type Portfolio struct {
+	Risk                     vulcanreport.SeverityRank `json:"risk"`
+	Assets                   int                       `json:"assets"`
+	VulnerabilitiesPerImpact map[string]int            `json:"vulnerabilities_per_impact"`
+	// Teams are sorted from the most to the least vulnerable.
+	Teams []TeamSummary `json:"teams"`
+	// SharedFindings contains the vulnerabilities found in the assets of
+	// more than one team.
+	SharedFindings []SharedFinding `json:"shared_findings"`
+}
+
+// TeamSummary contains the summary metrics of the scan of a team.
+type TeamSummary struct {
+	TeamID                   string                    `json:"team_id"`
+	TeamName                 string                    `json:"team_name"`
+	ScanID                   string                    `json:"scan_id"`
+	Date                     string                    `json:"date"`
+	Risk                     vulcanreport.SeverityRank `json:"risk"`
+	Assets                   int                       `json:"assets"`
+	VulnerableAssets         int                       `json:"vulnerable_assets"`
+	VulnerabilitiesPerImpact map[string]int            `json:"vulnerabilities_per_impact"`
+	MissingChecks            int                       `json:"missing_checks"`
+}
+
+// Issues returns the number of vulnerabilities with an impact over Info.
+func (t TeamSummary) Issues() int {
+	return countIssues(t.VulnerabilitiesPerImpact)
+}
+
+// SharedFinding is a vulnerability, identified by its summary, found in the
+// assets of several teams.
+type SharedFinding struct {
+	Summary string `json:"summary"`
+	// Severity is the highest severity the vulnerability has in the teams.
+	Severity vulcanreport.SeverityRank `json:"severity"`
+	Teams    []string                  `json:"teams"`
+	Assets   int                       `json:"assets"`
+}
+
+// NewPortfolio merges the report data of the scans of the given teams.
+func NewPortfolio(teams []TeamReportData) *Portfolio {
+	p := &Portfolio{
+		Risk:                     vulcanreport.SeverityNone,
+		VulnerabilitiesPerImpact: make(map[string]int),
+		Teams:                    []TeamSummary{},
+		SharedFindings:           []SharedFinding{},
+	}
+
+	// The teams are identified by their IDs, as several teams may have the
+	// same name, and the assets shared by several teams or scans are counted
+	// once.
+	type finding struct {
+		severity vulcanreport.SeverityRank
+		teams    map[string]string
+		assets   map[string]bool
+	}
+	findings := make(map[string]*finding)
+	assets := make(map[string]bool)
+	for _, t := range teams {
+		rd := t.Data
+		ts := TeamSummary{
+			TeamID:                   t.TeamID,
+			TeamName:                 t.TeamName,
+			ScanID:                   rd.ScanID,
+			Date:                     rd.Date,
+			Risk:                     rd.Risk,
+			Assets:                   len(rd.Assets),
+			VulnerableAssets:         rd.NumberOfVulnerableAssets,
+			VulnerabilitiesPerImpact: make(map[string]int),
+			MissingChecks:            len(rd.MissingChecks),
+		}
+		for _, v := range rd.Vulnerabilities {
+			severity := v.Vulnerability.Severity()
+			ts.VulnerabilitiesPerImpact[severityToString(severity)]++
+			p.VulnerabilitiesPerImpact[severityToString(severity)]++
+
+			// The informational findings are not relevant enough to be
+			// shown as shared.
+			if severity == vulcanreport.SeverityNone {
+				continue
+			}
+			f, ok := findings[v.Vulnerability.Summary]
+			if !ok {
+				f = &finding{teams: make(map[string]string), assets: make(map[string]bool)}
+				findings[v.Vulnerability.Summary] = f
+			}
+			if severity > f.severity {
+				f.severity = severity
+			}
+			f.teams[t.TeamID] = t.TeamName
+			f.assets[v.Asset] = true
+		}
+		if rd.Risk > p.Risk {
+			p.Risk = rd.Risk
+		}
+		for _, asset := range rd.Assets {
+			assets[asset] = true
+		}
+		p.Teams = append(p.Teams, ts)
+	}
+
+	sort.SliceStable(p.Teams, func(i, j int) bool {
+		if p.Teams[i].Risk == p.Teams[j].Risk {
+			if p.Teams[i].Issues() == p.Teams[j].Issues() {
+				return p.Teams[i].TeamName < p.Teams[j].TeamName
+			}
+			return p.Teams[i].Issues() > p.Teams[j].Issues()
+		}
+		return p.Teams[i].Risk > p.Teams[j].Risk
+	})
+	p.Assets = len(assets)
+
+	for summary, f := range findings {
+		if len(f.teams) < 2 {
+			continue
+		}
+		p.SharedFindings = append(p.SharedFindings, SharedFinding{
+			Summary:  summary,
+			Severity: f.severity,
+			Teams:    teamNames(f.teams),
+			Assets:   len(f.assets),
+		})
+	}
+	sort.SliceStable(p.SharedFindings, func(i, j int) bool {
+		a, b := p.SharedFindings[i], p.SharedFindings[j]
+		if a.Severity != b.Severity {
+			return a.Severity > b.Severity
+		}
+		if len(a.Teams) != len(b.Teams) {
+			return len(a.Teams) > len(b.Teams)
+		}
+		return a.Summary < b.Summary
+	})
+
+	return p
+}
+
+// teamNames returns the sorted names of the given teams, indexed by ID.
+func teamNames(teams map[string]string) []string {
+	names := make([]string, 0, len(teams))
+	for _, name := range teams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// countIssues returns the number of vulnerabilities with an impact over Info.
+func countIssues(perImpact map[string]int) int {
+	n := 0
+	for impact, count := range perImpact {
+		if impact != severityToString(vulcanreport.SeverityNone) {
+			n += count
+		}
+	}
+	return n
+}
@@ -0,0 +1,91 @@
+package vulcan
+
+import (
+	"reflect"
+	"testing"
+
+	vulcanreport "github.com/adevinta/vulcan-report"
+)
+
+func TestNewPortfolio(t *testing.T) {
+	finding := func(asset, summary string, score float32) Vulnerability {
+		return Vulnerability{Asset: asset, Vulnerability: vulcanreport.Vulnerability{Summary: summary, Score: score}}
+	}
+	teams := []TeamReportData{
+		{
+			TeamID:   "t1",
+			TeamName: "Web",
+			Data: &ReportData{
+				ScanID: "s1",
+				Risk:   vulcanreport.SeverityMedium,
+				Assets: []string{"shared.example.com", "web.example.com"},
+				Vulnerabilities: []Vulnerability{
+					finding("shared.example.com", "Weak TLS", 6.9),
+					finding("shared.example.com", "Server banner", 0),
+				},
+			},
+		},
+		{
+			// Another team with the same name.
+			TeamID:   "t2",
+			TeamName: "Web",
+			Data: &ReportData{
+				ScanID: "s2",
+				Risk:   vulcanreport.SeverityHigh,
+				Assets: []string{"shared.example.com", "api.example.com"},
+				Vulnerabilities: []Vulnerability{
+					finding("shared.example.com", "Weak TLS", 6.9),
+					finding("api.example.com", "Weak TLS", 8.9),
+					finding("shared.example.com", "Server banner", 0),
+				},
+			},
+		},
+		{
+			TeamID:   "t3",
+			TeamName: "Data",
+			Data: &ReportData{
+				ScanID:          "s3",
+				Risk:            vulcanreport.SeverityLow,
+				Assets:          []string{"db.example.com"},
+				Vulnerabilities: []Vulnerability{finding("db.example.com", "Open port", 3.9)},
+			},
+		},
+	}
+	p := NewPortfolio(teams)
+
+	if p.Risk != vulcanreport.SeverityHigh {
+		t.Errorf("unexpected risk: got %v, want %v", p.Risk, vulcanreport.SeverityHigh)
+	}
+	// The shared asset is counted once.
+	if p.Assets != 4 {
+		t.Errorf("unexpected assets: got %d, want 4", p.Assets)
+	}
+	wantImpact := map[string]int{"High": 1, "Medium": 2, "Low": 1, "Info": 2}
+	if !reflect.DeepEqual(p.VulnerabilitiesPerImpact, wantImpact) {
+		t.Errorf("unexpected vulnerabilities per impact: got %v, want %v", p.VulnerabilitiesPerImpact, wantImpact)
+	}
+
+	var scans []string
+	for _, ts := range p.Teams {
+		scans = append(scans, ts.ScanID)
+	}
+	if want := []string{"s2", "s1", "s3"}; !reflect.DeepEqual(scans, want) {
+		t.Errorf("unexpected order of the teams: got %v, want %v", scans, want)
+	}
+
+	// The informational findings are not shared, and the teams with the
+	// same name are different teams.
+	want := []SharedFinding{
+		{Summary: "Weak TLS", Severity: vulcanreport.SeverityHigh, Teams: []string{"Web", "Web"}, Assets: 2},
+	}
+	if !reflect.DeepEqual(p.SharedFindings, want) {
+		t.Errorf("unexpected shared findings: got %+v, want %+v", p.SharedFindings, want)
+	}
+}
+
+func TestNewPortfolioEmpty(t *testing.T) {
+	p := NewPortfolio(nil)
+	if p.Risk != vulcanreport.SeverityNone || p.Assets != 0 || len(p.Teams) != 0 || p.SharedFindings == nil {
+		t.Errorf("unexpected portfolio: %+v", p)
+	}
+}
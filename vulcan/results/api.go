@@ -1,15 +1,35 @@
 package results
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 
+	"github.com/adevinta/security-overview/vulcan/client"
 	report "github.com/adevinta/vulcan-report"
 )
 
-// GetReport retrieves a report stored on vulcan results
-func GetReport(baseEndpoint, rurl string) (*report.Report, error) {
+var (
+	// ErrReportNotFound is returned when the report does not exist.
+	ErrReportNotFound = errors.New("report not found")
+	// ErrUnauthorized is matched by the errors returned when vulcan-results
+	// rejects the credentials of the request.
+	ErrUnauthorized = client.ErrUnauthorized
+	// ErrUpstream is matched by the errors returned when vulcan-results
+	// responds with an unexpected status code. Use errors.As with a
+	// *client.StatusError to get the status code and the body.
+	ErrUpstream = client.ErrUpstream
+)
+
+// GetReport retrieves a report stored on vulcan results. The request is sent
+// using the given HTTP client or, if it is nil, using http.DefaultClient.
+func GetReport(ctx context.Context, c *http.Client, baseEndpoint, rurl string) (*report.Report, error) {
+	if c == nil {
+		c = http.DefaultClient
+	}
 	u, err := url.Parse(baseEndpoint)
 	if err != nil {
 		return nil, err
@@ -19,12 +39,24 @@ func GetReport(baseEndpoint, rurl string) (*report.Report, error) {
 		return nil, err
 	}
 	u.Path = reportURL.Path
-	resp, err := http.Get(u.String())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.Do(req)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
+	err = client.CheckResponse(resp)
+	if errors.Is(err, client.ErrNotFound) {
+		return nil, fmt.Errorf("%w: %s", ErrReportNotFound, u.String())
+	}
+	if err != nil {
+		return nil, err
+	}
+
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, err
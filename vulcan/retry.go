@@ -0,0 +1,87 @@
+package vulcan
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/adevinta/security-overview/vulcan/client"
+)
+
+// retryPolicy defines how many times and how often a failed request is
+// retried.
+type retryPolicy struct {
+	Retries    int
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+// do calls f until it succeeds, it returns a non transient error, the retries
+// are exhausted or the context is done. Between calls it waits an
+// exponentially increasing backoff with full jitter.
+func (p retryPolicy) do(ctx context.Context, f func() error) error {
+	var err error
+	for attempt := 0; ; attempt++ {
+		err = f()
+		if err == nil || attempt >= p.Retries || !isTransient(ctx, err) {
+			return err
+		}
+
+		t := time.NewTimer(p.delay(attempt))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return err
+		case <-t.C:
+		}
+	}
+}
+
+// delay returns the time to wait before the retry following the given
+// attempt.
+func (p retryPolicy) delay(attempt int) time.Duration {
+	backoff := p.Backoff << uint(attempt)
+	if backoff <= 0 || (p.MaxBackoff > 0 && backoff > p.MaxBackoff) {
+		backoff = p.MaxBackoff
+	}
+	if backoff <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(backoff)))
+}
+
+// isTransient returns true if the error returned by a request is likely to
+// not happen again if the request is retried: server errors, timeouts and
+// connection resets. Errors caused by the context being done are not
+// transient.
+func isTransient(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	var statusErr *client.StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= 500 || statusErr.StatusCode == http.StatusTooManyRequests
+	}
+
+	// The deadline of a single request has been exceeded but not the one of
+	// the whole collection.
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	return errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, io.EOF)
+}
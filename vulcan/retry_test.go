@@ -0,0 +1,115 @@
+package vulcan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/adevinta/security-overview/vulcan/client"
+)
+
+func TestIsTransient(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		err  error
+		want bool
+	}{
+		{name: "server error", err: &client.StatusError{StatusCode: http.StatusBadGateway}, want: true},
+		{name: "too many requests", err: &client.StatusError{StatusCode: http.StatusTooManyRequests}, want: true},
+		{name: "wrapped server error", err: fmt.Errorf("report: %w", &client.StatusError{StatusCode: http.StatusInternalServerError}), want: true},
+		{name: "not found", err: &client.StatusError{StatusCode: http.StatusNotFound}, want: false},
+		{name: "unauthorized", err: &client.StatusError{StatusCode: http.StatusUnauthorized}, want: false},
+		{name: "request deadline", err: context.DeadlineExceeded, want: true},
+		{name: "network timeout", err: &net.OpError{Op: "read", Err: os.ErrDeadlineExceeded}, want: true},
+		{name: "connection reset", err: &net.OpError{Op: "read", Err: syscall.ECONNRESET}, want: true},
+		{name: "connection refused", err: &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}, want: true},
+		{name: "unexpected EOF", err: io.ErrUnexpectedEOF, want: true},
+		{name: "EOF", err: io.EOF, want: true},
+		{name: "other error", err: errors.New("invalid report"), want: false},
+		{name: "context done", ctx: canceled, err: &client.StatusError{StatusCode: http.StatusBadGateway}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := tt.ctx
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			if got := isTransient(ctx, tt.err); got != tt.want {
+				t.Errorf("unexpected result: got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyDo(t *testing.T) {
+	transient := &client.StatusError{StatusCode: http.StatusServiceUnavailable}
+	permanent := &client.StatusError{StatusCode: http.StatusNotFound}
+
+	tests := []struct {
+		name      string
+		retries   int
+		errs      []error // Errors returned by the calls, nil afterwards.
+		wantCalls int
+		wantErr   error
+	}{
+		{name: "success", retries: 2, wantCalls: 1},
+		{name: "transient error", retries: 2, errs: []error{transient}, wantCalls: 2},
+		{name: "retries exhausted", retries: 2, errs: []error{transient, transient, transient}, wantCalls: 3, wantErr: transient},
+		{name: "no retries", retries: 0, errs: []error{transient}, wantCalls: 1, wantErr: transient},
+		{name: "permanent error", retries: 2, errs: []error{permanent}, wantCalls: 1, wantErr: permanent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := retryPolicy{Retries: tt.retries, Backoff: time.Millisecond, MaxBackoff: time.Millisecond}
+			calls := 0
+			err := p.do(context.Background(), func() error {
+				calls++
+				if calls <= len(tt.errs) {
+					return tt.errs[calls-1]
+				}
+				return nil
+			})
+			if err != tt.wantErr {
+				t.Errorf("unexpected error: got %v, want %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("unexpected number of calls: got %d, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  retryPolicy
+		attempt int
+		max     time.Duration
+	}{
+		{name: "first attempt", policy: retryPolicy{Backoff: 10 * time.Millisecond}, attempt: 0, max: 10 * time.Millisecond},
+		{name: "exponential", policy: retryPolicy{Backoff: 10 * time.Millisecond}, attempt: 3, max: 80 * time.Millisecond},
+		{name: "max backoff", policy: retryPolicy{Backoff: 10 * time.Millisecond, MaxBackoff: 20 * time.Millisecond}, attempt: 3, max: 20 * time.Millisecond},
+		{name: "overflow", policy: retryPolicy{Backoff: time.Second, MaxBackoff: time.Minute}, attempt: 70, max: time.Minute},
+		{name: "no backoff", policy: retryPolicy{}, attempt: 2, max: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 100; i++ {
+				if got := tt.policy.delay(tt.attempt); got < 0 || got > tt.max {
+					t.Fatalf("unexpected delay: got %v, want between 0 and %v", got, tt.max)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,376 @@
+package vulcan
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/adevinta/security-overview/config"
+	"github.com/adevinta/security-overview/vulcan/cache"
+	"github.com/adevinta/security-overview/vulcan/client"
+	"github.com/adevinta/security-overview/vulcan/persistence"
+	"github.com/adevinta/security-overview/vulcan/results"
+	vulcanreport "github.com/adevinta/vulcan-report"
+)
+
+const (
+	// SourceVulcan identifies the source backed by vulcan-persistence and
+	// vulcan-results APIs.
+	SourceVulcan = "vulcan"
+	// SourceDir identifies the source backed by a local directory.
+	SourceDir = "dir"
+)
+
+// ReportSource defines the methods required to collect the data of a scan.
+type ReportSource interface {
+	// ScanDate returns the date, formatted as YYYY-MM-DD, when the scan
+	// started.
+	ScanDate(ctx context.Context, scanID string) (string, error)
+	// Checks returns an iterator over the checks of the scan.
+	Checks(scanID string) CheckIterator
+	// Report returns the report generated by a check.
+	Report(ctx context.Context, check persistence.Check) (*vulcanreport.Report, error)
+}
+
+// CheckIterator iterates over the checks of a scan, allowing backends to
+// stream them instead of retrieving all of them at once. The iterators that
+// know the number of checks in advance can also implement a Total() int
+// method returning it, or a negative number if it is not known, so the
+// progress of the collection can be estimated.
+type CheckIterator interface {
+	// Next advances the iterator to the next check. It returns false when
+	// there are no more checks or an error happened.
+	Next(ctx context.Context) bool
+	// Check returns the current check.
+	Check() persistence.Check
+	// Err returns the error, if any, that stopped the iteration.
+	Err() error
+}
+
+// NewHTTPTransport returns the HTTP transport defined in the config, meant to
+// be shared by all the outbound requests.
+func NewHTTPTransport(conf config.Config) (*http.Transport, error) {
+	return client.NewTransport(client.Options{
+		CABundle:            conf.HTTP.CABundle,
+		Proxy:               conf.HTTP.Proxy,
+		MaxIdleConns:        conf.HTTP.MaxIdleConns,
+		MaxIdleConnsPerHost: conf.HTTP.MaxIdleConnsPerHost,
+		MaxConnsPerHost:     conf.HTTP.MaxConnsPerHost,
+		IdleConnTimeout:     conf.HTTP.IdleConnTimeout,
+		DisableKeepAlives:   conf.HTTP.DisableKeepAlives,
+		DisableCompression:  conf.HTTP.DisableCompression,
+	})
+}
+
+// NewReportSource returns the ReportSource defined in the config. The
+// requests sent by the source use the given transport.
+func NewReportSource(conf config.Config, transport *http.Transport) (ReportSource, error) {
+	switch conf.Source.Type {
+	case "", SourceVulcan:
+		persistenceClient, err := client.NewAuthClient(transport, authFromConfig(conf.Persistence.Auth))
+		if err != nil {
+			return nil, err
+		}
+		resultsClient, err := client.NewAuthClient(transport, authFromConfig(conf.Results.Auth))
+		if err != nil {
+			return nil, err
+		}
+		if err := setupCache(conf, persistenceClient, resultsClient); err != nil {
+			return nil, err
+		}
+		return &VulcanSource{
+			PersistenceClient:   persistenceClient,
+			ResultsClient:       resultsClient,
+			PersistenceEndpoint: conf.Persistence.Endpoint,
+			PersistenceTimeout:  conf.Persistence.RequestTimeout,
+			PageSize:            conf.Persistence.PageSize,
+			ResultsEndpoint:     conf.Results.Endpoint,
+			ResultsTimeout:      conf.Results.RequestTimeout,
+		}, nil
+	case SourceDir:
+		if conf.Source.Dir == "" {
+			return nil, errors.New("source dir not specified")
+		}
+		return &DirSource{Dir: conf.Source.Dir}, nil
+	default:
+		return nil, fmt.Errorf("unknown source type: %s", conf.Source.Type)
+	}
+}
+
+// setupCache makes the clients use the cache defined in the config, if any.
+// The reports are served from the cache, while the responses of
+// vulcan-persistence, that change while a scan is running, are only cached to
+// be used in offline mode.
+func setupCache(conf config.Config, persistenceClient, resultsClient *http.Client) error {
+	if conf.Cache.Dir == "" {
+		if conf.Cache.Offline {
+			return errors.New("offline mode requires a cache dir")
+		}
+		return nil
+	}
+	c, err := cache.New(conf.Cache.Dir, conf.Cache.TTL, conf.Cache.MaxSizeMB*1024*1024)
+	if err != nil {
+		return err
+	}
+	persistenceMode, resultsMode := cache.ModeWriteOnly, cache.ModeReadThrough
+	if conf.Cache.Offline {
+		persistenceMode, resultsMode = cache.ModeOffline, cache.ModeOffline
+	}
+	persistenceClient.Transport = &cache.Transport{Base: persistenceClient.Transport, Cache: c, Mode: persistenceMode}
+	resultsClient.Transport = &cache.Transport{Base: resultsClient.Transport, Cache: c, Mode: resultsMode}
+	return nil
+}
+
+func authFromConfig(auth config.AuthConfig) client.Auth {
+	return client.Auth{
+		Token:      auth.Token,
+		Username:   auth.Username,
+		Password:   auth.Password,
+		ClientCert: auth.ClientCert,
+		ClientKey:  auth.ClientKey,
+	}
+}
+
+// VulcanSource collects the data of a scan from vulcan-persistence and
+// vulcan-results APIs. Every request is bounded by the timeout defined for its
+// API, if any. Nil HTTP clients mean http.DefaultClient.
+type VulcanSource struct {
+	PersistenceClient   *http.Client
+	ResultsClient       *http.Client
+	PersistenceEndpoint string
+	PersistenceTimeout  time.Duration
+	PageSize            int
+	ResultsEndpoint     string
+	ResultsTimeout      time.Duration
+}
+
+// ScanDate retrieves the date of the scan from vulcan-persistence.
+func (s *VulcanSource) ScanDate(ctx context.Context, scanID string) (string, error) {
+	ctx, cancel := withTimeout(ctx, s.PersistenceTimeout)
+	defer cancel()
+	return persistence.GetDate(ctx, s.PersistenceClient, s.PersistenceEndpoint, scanID)
+}
+
+// Checks retrieves the checks of the scan from vulcan-persistence, one page
+// at a time.
+func (s *VulcanSource) Checks(scanID string) CheckIterator {
+	return &timeoutIterator{
+		CheckIterator: persistence.NewChecksIterator(s.PersistenceClient, s.PersistenceEndpoint, scanID, s.PageSize),
+		timeout:       s.PersistenceTimeout,
+	}
+}
+
+// Report retrieves the report of the check from vulcan-results.
+func (s *VulcanSource) Report(ctx context.Context, check persistence.Check) (*vulcanreport.Report, error) {
+	ctx, cancel := withTimeout(ctx, s.ResultsTimeout)
+	defer cancel()
+	return results.GetReport(ctx, s.ResultsClient, s.ResultsEndpoint, check.Report)
+}
+
+// timeoutIterator bounds every call to the Next method of a CheckIterator,
+// and so every page requested, by a timeout.
+type timeoutIterator struct {
+	CheckIterator
+	timeout time.Duration
+}
+
+func (it *timeoutIterator) Next(ctx context.Context) bool {
+	ctx, cancel := withTimeout(ctx, it.timeout)
+	defer cancel()
+	return it.CheckIterator.Next(ctx)
+}
+
+func (it *timeoutIterator) Total() int {
+	if t, ok := it.CheckIterator.(totaler); ok {
+		return t.Total()
+	}
+	return -1
+}
+
+// sliceIterator is a CheckIterator over a slice of checks.
+type sliceIterator struct {
+	checks  []persistence.Check
+	total   int
+	current persistence.Check
+	err     error
+}
+
+func (it *sliceIterator) Next(ctx context.Context) bool {
+	if it.err != nil {
+		return false
+	}
+	if err := ctx.Err(); err != nil {
+		it.err = err
+		return false
+	}
+	if len(it.checks) == 0 {
+		return false
+	}
+	it.current = it.checks[0]
+	it.checks = it.checks[1:]
+	return true
+}
+
+func (it *sliceIterator) Check() persistence.Check {
+	return it.current
+}
+
+func (it *sliceIterator) Err() error {
+	return it.err
+}
+
+func (it *sliceIterator) Total() int {
+	return it.total
+}
+
+// withTimeout returns a copy of the context bounded by the given timeout.
+// A zero timeout means no timeout.
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// DirSource collects the data of a scan from a local directory, for instance
+// an archived dump of a scan. Every scan is stored in its own folder:
+//
+//	<dir>/
+//	'
+//	'--<scan-id>/
+//	       '
+//	       '--scan.json             (response from /v1/scans/{id})
+//	       '
+//	       '--checks.json           (response from /v1/scans/{id}/checks, optional)
+//	       '
+//	       '--reports/
+//	              '
+//	              '--<check-id>.json
+//
+// When checks.json does not exist, the checks are built from the reports
+// stored in the reports folder.
+type DirSource struct {
+	Dir string
+}
+
+// ScanDate reads the date of the scan from the scan.json file.
+func (s *DirSource) ScanDate(ctx context.Context, scanID string) (string, error) {
+	if err := checkScanID(scanID); err != nil {
+		return "", err
+	}
+	content, err := os.ReadFile(filepath.Join(s.Dir, scanID, "scan.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("%w: %s", persistence.ErrScanNotFound, scanID)
+	}
+	if err != nil {
+		return "", err
+	}
+	scan := &persistence.Scan{}
+	if err := json.Unmarshal(content, scan); err != nil {
+		return "", err
+	}
+	// An empty start time means the scan does not exist, as in
+	// vulcan-persistence.
+	if scan.StartTime.IsZero() {
+		return "", fmt.Errorf("%w: %s", persistence.ErrScanNotFound, scanID)
+	}
+	return scan.StartTime.Format("2006-01-02"), nil
+}
+
+// Checks reads the checks of the scan from the checks.json file or, when it
+// does not exist, from the reports folder.
+func (s *DirSource) Checks(scanID string) CheckIterator {
+	checks, err := s.readChecks(scanID)
+	return &sliceIterator{checks: checks, total: len(checks), err: err}
+}
+
+func (s *DirSource) readChecks(scanID string) ([]persistence.Check, error) {
+	if err := checkScanID(scanID); err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(filepath.Join(s.Dir, scanID, "checks.json"))
+	if err == nil {
+		checksResp := &persistence.Checks{}
+		if err := json.Unmarshal(content, checksResp); err != nil {
+			return nil, err
+		}
+		// The report field of the checks points to vulcan-results, so we
+		// replace it with the path of the report in the reports folder.
+		checks := checksResp.Checks
+		for i := range checks {
+			checks[i].Report = filepath.Join(scanID, "reports", checks[i].ID+".json")
+		}
+		return checks, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	files, err := os.ReadDir(filepath.Join(s.Dir, scanID, "reports"))
+	if err != nil {
+		return nil, err
+	}
+	checks := []persistence.Check{}
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != ".json" {
+			continue
+		}
+		r, err := readReport(filepath.Join(s.Dir, scanID, "reports", file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		checks = append(checks, persistence.Check{
+			ID:            strings.TrimSuffix(file.Name(), ".json"),
+			Target:        r.Target,
+			Status:        r.Status,
+			Report:        filepath.Join(scanID, "reports", file.Name()),
+			CheckTypeName: r.ChecktypeName,
+		})
+	}
+	sort.SliceStable(checks, func(i, j int) bool {
+		return checks[i].ID < checks[j].ID
+	})
+	return checks, nil
+}
+
+// Report reads the report of the check from the reports folder.
+func (s *DirSource) Report(ctx context.Context, check persistence.Check) (*vulcanreport.Report, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return readReport(filepath.Join(s.Dir, check.Report))
+}
+
+// checkScanID returns an error if the scan ID is not a single path element,
+// so the files of the scans can not be read from outside the directory.
+func checkScanID(scanID string) error {
+	if scanID == "" || scanID == "." || scanID == ".." || strings.ContainsAny(scanID, `/\`) {
+		return fmt.Errorf("%w: invalid scan ID %q", persistence.ErrScanNotFound, scanID)
+	}
+	return nil
+}
+
+// readReport reads a check report from a file. The times of the report can
+// be stored either as strings, like vulcan-results does, or as RFC3339.
+func readReport(path string) (*vulcanreport.Report, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	r := &vulcanreport.Report{}
+	if err := r.UnmarshalJSONTimeAsString(content); err == nil {
+		return r, nil
+	}
+	r = &vulcanreport.Report{}
+	if err := json.Unmarshal(content, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
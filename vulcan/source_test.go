@@ -0,0 +1,158 @@
+package vulcan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/adevinta/security-overview/vulcan/persistence"
+)
+
+const testReport = `{"check_id":"%s","checktype_name":"vulcan-tls","status":"FINISHED","target":"example.com","start_time":"2022-10-10 10:00:00"}`
+
+// writeFiles writes the files, indexed by their path relative to the
+// directory.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestDirSourceScanDate(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"outside/scan.json":       `{"id":"outside","start_time":"2022-10-12T10:00:00Z"}`,
+		"dir/scan/scan.json":      `{"id":"scan","start_time":"2022-10-12T10:00:00Z"}`,
+		"dir/no-scan/checks.json": `{"checks":[]}`,
+	})
+	s := &DirSource{Dir: filepath.Join(dir, "dir")}
+
+	tests := []struct {
+		name    string
+		scanID  string
+		want    string
+		wantErr error
+	}{
+		{name: "scan", scanID: "scan", want: "2022-10-12"},
+		{name: "no scan file", scanID: "no-scan", wantErr: persistence.ErrScanNotFound},
+		{name: "unknown scan", scanID: "unknown", wantErr: persistence.ErrScanNotFound},
+		{name: "empty scan ID", scanID: "", wantErr: persistence.ErrScanNotFound},
+		{name: "dot", scanID: ".", wantErr: persistence.ErrScanNotFound},
+		{name: "parent", scanID: "..", wantErr: persistence.ErrScanNotFound},
+		{name: "path", scanID: "../outside", wantErr: persistence.ErrScanNotFound},
+		{name: "windows path", scanID: `..\outside`, wantErr: persistence.ErrScanNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.ScanDate(context.Background(), tt.scanID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("unexpected error: got %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("unexpected date: got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDirSourceScanDateInvalid(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"no-start/scan.json": `{"id":"no-start"}`,
+		"invalid/scan.json":  `{`,
+	})
+	s := &DirSource{Dir: dir}
+
+	tests := []struct {
+		name         string
+		scanID       string
+		wantNotFound bool
+	}{
+		{name: "no start time", scanID: "no-start", wantNotFound: true},
+		{name: "invalid JSON", scanID: "invalid", wantNotFound: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.ScanDate(context.Background(), tt.scanID)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if got := errors.Is(err, persistence.ErrScanNotFound); got != tt.wantNotFound {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestDirSourceChecks(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"checks/checks.json":      `{"checks":[{"id":"c2","target":"example.com","status":"FINISHED","report":"http://results/c2","checktype_name":"vulcan-tls"}]}`,
+		"checks/reports/c2.json":  fmt.Sprintf(testReport, "c2"),
+		"reports/reports/c2.json": fmt.Sprintf(testReport, "c2"),
+		"reports/reports/c1.json": fmt.Sprintf(testReport, "c1"),
+		"reports/reports/c3.txt":  "ignored",
+	})
+	s := &DirSource{Dir: dir}
+
+	tests := []struct {
+		name    string
+		scanID  string
+		want    []persistence.Check
+		wantErr bool
+	}{
+		{
+			name:   "checks file",
+			scanID: "checks",
+			want: []persistence.Check{
+				{ID: "c2", Target: "example.com", Status: "FINISHED", Report: filepath.Join("checks", "reports", "c2.json"), CheckTypeName: "vulcan-tls"},
+			},
+		},
+		{
+			name:   "reports folder",
+			scanID: "reports",
+			want: []persistence.Check{
+				{ID: "c1", Target: "example.com", Status: "FINISHED", Report: filepath.Join("reports", "reports", "c1.json"), CheckTypeName: "vulcan-tls"},
+				{ID: "c2", Target: "example.com", Status: "FINISHED", Report: filepath.Join("reports", "reports", "c2.json"), CheckTypeName: "vulcan-tls"},
+			},
+		},
+		{name: "unknown scan", scanID: "unknown", wantErr: true},
+		{name: "invalid scan ID", scanID: "../reports", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			it := s.Checks(tt.scanID)
+			var got []persistence.Check
+			for it.Next(ctx) {
+				got = append(got, it.Check())
+			}
+			if err := it.Err(); (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("unexpected checks: got %+v, want %+v", got, tt.want)
+			}
+			for _, c := range got {
+				r, err := s.Report(ctx, c)
+				if err != nil {
+					t.Fatalf("error reading the report of %s: %v", c.ID, err)
+				}
+				if r.CheckID != c.ID {
+					t.Errorf("unexpected report: got %s, want %s", r.CheckID, c.ID)
+				}
+			}
+		})
+	}
+}
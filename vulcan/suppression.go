@@ -0,0 +1,114 @@
+package vulcan
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// SuppressionRule defines the findings whose risk has been accepted or that
+// are false positives, so they are not included in the reports.
+type SuppressionRule struct {
+	FindingFilter
+	Reason string `toml:"reason" json:"reason"`
+	Owner  string `toml:"owner" json:"owner"`
+	// Expires is the last date, formatted as YYYY-MM-DD, when the rule
+	// applies. An empty date means the rule does not expire.
+	Expires string `toml:"expires" json:"expires,omitempty"`
+}
+
+// Suppressions contains the rules read from a suppression file.
+type Suppressions struct {
+	Rules []SuppressionRule `toml:"suppression"`
+}
+
+// SuppressedFinding is a finding suppressed by a rule.
+type SuppressedFinding struct {
+	Vulnerability
+	Rule SuppressionRule `json:"rule"`
+}
+
+// ReadSuppressions reads the rules defined in a TOML file like the following:
+//
+//	[[suppression]]
+//	asset = "*.example.com"
+//	checktype = "vulcan-tls"
+//	summary = "^Weak SSL/TLS"
+//	reason = "Required by legacy clients"
+//	owner = "jane@example.com"
+//	expires = "2027-06-30"
+func ReadSuppressions(file string) (*Suppressions, error) {
+	s := &Suppressions{}
+	if _, err := toml.DecodeFile(file, s); err != nil {
+		return nil, err
+	}
+	for i := range s.Rules {
+		if err := s.Rules[i].compile(); err != nil {
+			return nil, fmt.Errorf("invalid suppression rule %d: %w", i+1, err)
+		}
+	}
+	return s, nil
+}
+
+func (r *SuppressionRule) compile() error {
+	if err := r.FindingFilter.compile(); err != nil {
+		return err
+	}
+	if r.Reason == "" || r.Owner == "" {
+		return errors.New("reason and owner are required")
+	}
+	if r.Expires != "" {
+		if _, err := time.Parse(dateLayout, r.Expires); err != nil {
+			return fmt.Errorf("invalid expiry date: %w", err)
+		}
+	}
+	return nil
+}
+
+// expired returns true if the rule does not apply on the given date.
+func (r SuppressionRule) expired(date string) bool {
+	return r.Expires != "" && r.Expires < date
+}
+
+// Match returns the first rule not expired on the given date suppressing the
+// finding, if any.
+func (s *Suppressions) Match(v Vulnerability, date string) (SuppressionRule, bool) {
+	if s == nil {
+		return SuppressionRule{}, false
+	}
+	for _, r := range s.Rules {
+		if !r.expired(date) && r.match(v) {
+			return r, true
+		}
+	}
+	return SuppressionRule{}, false
+}
+
+// Expired returns the rules expired on the given date.
+func (s *Suppressions) Expired(date string) []SuppressionRule {
+	result := []SuppressionRule{}
+	if s == nil {
+		return result
+	}
+	for _, r := range s.Rules {
+		if r.expired(date) {
+			result = append(result, r)
+		}
+	}
+	return result
+}
+
+func sortSuppressed(findings []SuppressedFinding) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Asset == findings[j].Asset {
+			if findings[i].CheckType == findings[j].CheckType {
+				return findings[i].Vulnerability.Vulnerability.Summary < findings[j].Vulnerability.Vulnerability.Summary
+			}
+			return findings[i].CheckType < findings[j].CheckType
+		}
+		return findings[i].Asset < findings[j].Asset
+	})
+}
@@ -0,0 +1,184 @@
+package vulcan
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/adevinta/vulcan-groupie/db"
+	"github.com/adevinta/vulcan-groupie/pkg/groupie"
+	vulcanreport "github.com/adevinta/vulcan-report"
+)
+
+func TestReadSuppressions(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		wantRules int
+		wantErr   bool
+	}{
+		{
+			name: "valid",
+			content: `
+[[suppression]]
+asset = "*.example.com"
+checktype = "vulcan-tls"
+summary = "^Weak SSL/TLS"
+reason = "Required by legacy clients"
+owner = "jane@example.com"
+expires = "2022-10-31"
+
+[[suppression]]
+fingerprint = "abc"
+reason = "False positive"
+owner = "jane@example.com"
+`,
+			wantRules: 2,
+		},
+		{name: "no rules", content: "", wantRules: 0},
+		{name: "no criteria", content: "[[suppression]]\nreason = \"r\"\nowner = \"o\"\n", wantErr: true},
+		{name: "no reason", content: "[[suppression]]\nchecktype = \"vulcan-tls\"\nowner = \"o\"\n", wantErr: true},
+		{name: "no owner", content: "[[suppression]]\nchecktype = \"vulcan-tls\"\nreason = \"r\"\n", wantErr: true},
+		{name: "invalid summary", content: "[[suppression]]\nsummary = \"(\"\nreason = \"r\"\nowner = \"o\"\n", wantErr: true},
+		{name: "invalid glob", content: "[[suppression]]\nasset = \"[\"\nreason = \"r\"\nowner = \"o\"\n", wantErr: true},
+		{name: "invalid expiry", content: "[[suppression]]\nchecktype = \"vulcan-tls\"\nreason = \"r\"\nowner = \"o\"\nexpires = \"31/10/2022\"\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, map[string]string{"suppressions.toml": tt.content})
+			s, err := ReadSuppressions(filepath.Join(dir, "suppressions.toml"))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err == nil && len(s.Rules) != tt.wantRules {
+				t.Errorf("unexpected number of rules: got %d, want %d", len(s.Rules), tt.wantRules)
+			}
+		})
+	}
+}
+
+func TestSuppressionsMatch(t *testing.T) {
+	temporary := SuppressionRule{
+		FindingFilter: FindingFilter{Asset: "*.example.com", Summary: "^Weak TLS"},
+		Reason:        "Required by legacy clients",
+		Owner:         "jane@example.com",
+		Expires:       "2022-10-31",
+	}
+	permanent := SuppressionRule{
+		FindingFilter: FindingFilter{CheckType: "vulcan-exposed-http", Fingerprint: "abc"},
+		Reason:        "False positive",
+		Owner:         "jane@example.com",
+	}
+	s := &Suppressions{Rules: []SuppressionRule{temporary, permanent}}
+	for i := range s.Rules {
+		if err := s.Rules[i].compile(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	finding := func(asset, checktype, summary, fingerprint string) Vulnerability {
+		return Vulnerability{Asset: asset, CheckType: checktype, Vulnerability: vulcanreport.Vulnerability{Summary: summary, Fingerprint: fingerprint}}
+	}
+
+	tests := []struct {
+		name       string
+		v          Vulnerability
+		date       string
+		wantMatch  bool
+		wantReason string
+	}{
+		{name: "match", v: finding("www.example.com", "vulcan-tls", "Weak TLS ciphers", ""), date: "2022-10-12", wantMatch: true, wantReason: temporary.Reason},
+		{name: "last day", v: finding("www.example.com", "vulcan-tls", "Weak TLS ciphers", ""), date: "2022-10-31", wantMatch: true, wantReason: temporary.Reason},
+		{name: "expired", v: finding("www.example.com", "vulcan-tls", "Weak TLS ciphers", ""), date: "2022-11-01", wantMatch: false},
+		{name: "other asset", v: finding("www.example.org", "vulcan-tls", "Weak TLS ciphers", ""), date: "2022-10-12", wantMatch: false},
+		{name: "other summary", v: finding("www.example.com", "vulcan-tls", "Expired certificate", ""), date: "2022-10-12", wantMatch: false},
+		{name: "never expires", v: finding("www.example.org", "vulcan-exposed-http", "Exposed file", "abc"), date: "2030-01-01", wantMatch: true, wantReason: permanent.Reason},
+		{name: "other fingerprint", v: finding("www.example.org", "vulcan-exposed-http", "Exposed file", "def"), date: "2022-10-12", wantMatch: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, ok := s.Match(tt.v, tt.date)
+			if ok != tt.wantMatch {
+				t.Fatalf("unexpected match: got %v, want %v", ok, tt.wantMatch)
+			}
+			if rule.Reason != tt.wantReason {
+				t.Errorf("unexpected rule: got %q, want %q", rule.Reason, tt.wantReason)
+			}
+		})
+	}
+
+	var none *Suppressions
+	if _, ok := none.Match(tests[0].v, "2022-10-12"); ok {
+		t.Error("unexpected match without suppressions")
+	}
+}
+
+func TestSuppressionsExpired(t *testing.T) {
+	s := &Suppressions{Rules: []SuppressionRule{
+		{Reason: "expired", Expires: "2022-10-01"},
+		{Reason: "last day", Expires: "2022-10-12"},
+		{Reason: "future", Expires: "2022-10-31"},
+		{Reason: "permanent"},
+	}}
+
+	tests := []struct {
+		name string
+		s    *Suppressions
+		date string
+		want []string
+	}{
+		{name: "expired rules", s: s, date: "2022-10-12", want: []string{"expired"}},
+		{name: "all expired", s: s, date: "2022-11-01", want: []string{"expired", "last day", "future"}},
+		{name: "none expired", s: s, date: "2022-09-01", want: []string{}},
+		{name: "no suppressions", s: nil, date: "2022-10-12", want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []string{}
+			for _, r := range tt.s.Expired(tt.date) {
+				got = append(got, r.Reason)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("unexpected expired rules: got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("unexpected expired rules: got %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestAggregatorSuppressions(t *testing.T) {
+	s := &Suppressions{Rules: []SuppressionRule{{
+		FindingFilter: FindingFilter{CheckType: "vulcan-tls"},
+		Reason:        "Required by legacy clients",
+		Owner:         "jane@example.com",
+	}}}
+	if err := s.Rules[0].compile(); err != nil {
+		t.Fatal(err)
+	}
+	a := newAggregator("scan", "2022-10-12", groupie.New(db.NewMemDB()), s, nil, nil)
+	// The check is run twice against the asset.
+	for i := 0; i < 2; i++ {
+		a.add(context.Background(), newTestReport("example.com", "vulcan-tls", vulcanreport.Vulnerability{Summary: "Weak TLS", Score: 6.9}), AssetTypeHostname)
+	}
+	a.add(context.Background(), newTestReport("example.com", "vulcan-nmap", vulcanreport.Vulnerability{Summary: "Open port", Score: 3.9}), AssetTypeHostname)
+	rd := &ReportData{}
+	if err := a.apply(rd); err != nil {
+		t.Fatal(err)
+	}
+
+	// The suppressed findings are recorded once and are not part of the rest
+	// of the report.
+	if len(rd.Suppressed) != 1 || rd.Suppressed[0].Vulnerability.Vulnerability.Summary != "Weak TLS" {
+		t.Errorf("unexpected suppressed findings: %+v", rd.Suppressed)
+	}
+	if len(rd.Vulnerabilities) != 1 || rd.Vulnerabilities[0].Vulnerability.Summary != "Open port" {
+		t.Errorf("unexpected vulnerabilities: %+v", rd.Vulnerabilities)
+	}
+	if rd.Risk != vulcanreport.SeverityLow {
+		t.Errorf("unexpected risk: got %v, want %v", rd.Risk, vulcanreport.SeverityLow)
+	}
+}
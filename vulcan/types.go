@@ -1,23 +1,25 @@
 package vulcan
 
 import (
-	"sync"
-
-	"github.com/adevinta/security-overview/vulcan/persistence"
+	"github.com/adevinta/security-overview/config"
 	"github.com/adevinta/vulcan-groupie/pkg/groupie"
 	"github.com/adevinta/vulcan-groupie/pkg/models"
 	vulcanreport "github.com/adevinta/vulcan-report"
 )
 
+// StatusFinished is the status of the checks that finished successfully.
+const StatusFinished = "FINISHED"
+
 // ReportData contains all required data for a detailed report
 type ReportData struct {
-	ScanID  string `json:"scan_id"`
-	Date    string
-	Reports []vulcanreport.Report
+	ScanID string `json:"scan_id"`
+	Date   string
 
 	Risk                     vulcanreport.SeverityRank  `json:"risk"`
 	ActionRequired           bool                       `json:"action_required"`
 	Assets                   []string                   `json:"assets"`
+	AssetsMetadata           map[string]AssetMetadata   `json:"assets_metadata,omitempty"`
+	AssetTypes               map[string]string          `json:"asset_types"`
 	CheckTypes               []string                   `json:"checktypes"`
 	VulnerabilitiesPerImpact []VulnerabilitiesPerImpact `json:"vulnerabilities_per_impact"`
 	VulnerabilitiesPerAsset  []VulnerabilitiesPerAsset  `json:"vulnerabilities_per_asset"`
@@ -26,13 +28,22 @@ type ReportData struct {
 	Vulnerabilities          []Vulnerability            `json:"vulnerabilities"`
 	Groups                   []models.Group             `json:"groups"`
 	GroupsPerAsset           map[string][]models.Group  `json:"groups_per_asset"`
+	MissingChecks            []MissingCheck             `json:"missing_checks"`
+	Coverage                 []AssetCoverage            `json:"coverage"`
+	Diff                     *Diff                      `json:"diff,omitempty"`
+	Suppressed               []SuppressedFinding        `json:"suppressed"`
+	ExpiredSuppressions      []SuppressionRule          `json:"expired_suppressions"`
+	Overrides                []OverriddenFinding        `json:"overrides"`
 
-	reportWG    sync.WaitGroup
-	workerWG    sync.WaitGroup
-	countChecks int
-	mu          sync.RWMutex
-	chanChecks  chan persistence.Check
-	groupie     *groupie.Groupie
+	countChecks  int
+	groupie      *groupie.Groupie
+	retry        retryPolicy
+	coverage     map[string][]CheckCoverage
+	aggregator   *aggregator
+	findingDates map[string]FindingDates
+	overrides    map[string]*ScoreOverride
+	grouped      map[string][]Vulnerability
+	sla          config.SLAConfig
 }
 
 // VulnerabilitiesPerImpact associates an impact with a number of vulnerabilities
@@ -57,8 +68,42 @@ type VulnerabilityCount struct {
 // Vulnerability represents a vulnerability found on an asset by a checktype
 type Vulnerability struct {
 	Asset           string                     `json:"asset"`
+	AssetType       string                     `json:"asset_type,omitempty"`
 	AffectedTargets []string                   `json:"affected_targets"`
 	CheckType       string                     `json:"checktype"`
 	Options         string                     `json:"options"`
 	Vulnerability   vulcanreport.Vulnerability `json:"vulnerability"`
+	// FirstSeen is the date when the finding was found for the first time.
+	// It is only known when the history is enabled.
+	FirstSeen   string `json:"first_seen,omitempty"`
+	OpenDays    int    `json:"open_days,omitempty"`
+	SLABreached bool   `json:"sla_breached,omitempty"`
+	// Override records the change of the score of the finding by the
+	// policy, if any.
+	Override *ScoreOverride `json:"override,omitempty"`
+}
+
+// MissingCheck represents a check whose report could not be retrieved, so its
+// results are not included in the report.
+type MissingCheck struct {
+	CheckID   string `json:"check_id"`
+	Target    string `json:"target"`
+	CheckType string `json:"checktype"`
+	Error     string `json:"error"`
+}
+
+// AssetCoverage contains the checks run against an asset, so it can be told
+// whether an asset without vulnerabilities has been actually scanned.
+type AssetCoverage struct {
+	Asset  string          `json:"asset"`
+	Checks []CheckCoverage `json:"checks"`
+	Failed int             `json:"failed"`
+}
+
+// CheckCoverage represents the execution of a checktype against an asset.
+// Reason explains why the check did not finish or its results are missing.
+type CheckCoverage struct {
+	CheckType string `json:"checktype"`
+	Status    string `json:"status"`
+	Reason    string `json:"reason,omitempty"`
 }
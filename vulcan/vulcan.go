@@ -3,108 +3,293 @@ package vulcan
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log"
 	"os"
 	"sort"
+	"sync"
 	"time"
 
 	"github.com/adevinta/security-overview/config"
+	"github.com/adevinta/security-overview/vulcan/cache"
 	"github.com/adevinta/security-overview/vulcan/persistence"
-	"github.com/adevinta/security-overview/vulcan/results"
 	"github.com/adevinta/vulcan-groupie/db"
 	"github.com/adevinta/vulcan-groupie/pkg/groupie"
 	"github.com/adevinta/vulcan-groupie/pkg/models"
 	vulcanreport "github.com/adevinta/vulcan-report"
 )
 
-func (rp *ReportData) worker(done <-chan struct{}, conf config.Config) {
-	defer rp.workerWG.Done()
-	var exit bool
-
-	for !exit {
-		select {
-		case check := <-rp.chanChecks:
-			report, err := results.GetReport(conf.Results.Endpoint, check.Report)
-			if err != nil {
-				log.Printf("ERROR getting results for check-id: %s. Error detail:%v.\n The security overview will not include results of these checks.", check, err)
-				rp.reportWG.Done()
-				continue
-			}
+// process retrieves the report of a check, retrying the transient errors.
+func (rp *ReportData) process(ctx context.Context, source ReportSource, check persistence.Check) checkResult {
+	// The checks that did not finish do not have vulnerabilities, but they
+	// are part of the coverage of the scan.
+	if check.Status != "" && check.Status != StatusFinished {
+		return checkResult{check: check, reason: checkFailureReason(ctx, source, check)}
+	}
 
-			rp.mu.Lock()
-			rp.countChecks++
-			if rp.countChecks%100 == 0 {
-				log.Printf("%d", rp.countChecks)
-			}
-			//time.Sleep(200 * time.Millisecond)
+	var report *vulcanreport.Report
+	err := rp.retry.do(ctx, func() error {
+		var err error
+		report, err = source.Report(ctx, check)
+		return err
+	})
+	return checkResult{check: check, report: report, err: err}
+}
 
-			rp.Reports = append(rp.Reports, *report)
-			rp.mu.Unlock()
+// collect updates the report data with the result of processing a check. It
+// must not be called concurrently.
+func (rp *ReportData) collect(ctx context.Context, res checkResult) {
+	switch {
+	case res.err != nil:
+		log.Printf("ERROR getting results for check-id: %s. Error detail:%v.\n The security overview will not include results of these checks.", res.check.ID, res.err)
+		rp.MissingChecks = append(rp.MissingChecks, MissingCheck{
+			CheckID:   res.check.ID,
+			Target:    res.check.Target,
+			CheckType: res.check.CheckTypeName,
+			Error:     res.err.Error(),
+		})
+		rp.addCoverage(res.check, "The results of the check could not be retrieved")
+	case res.report == nil:
+		rp.addCoverage(res.check, res.reason)
+	default:
+		rp.addCoverage(res.check, "")
+		rp.countChecks++
+		rp.aggregator.add(ctx, res.report, rp.AssetTypes[res.check.Target])
+	}
+}
 
-			rp.reportWG.Done()
-		case <-done:
-			exit = true
-		}
+// checkFailureReason returns the reason why a check did not finish, taken
+// from the error of its report, if any.
+func checkFailureReason(ctx context.Context, source ReportSource, check persistence.Check) string {
+	if check.Report == "" {
+		return ""
+	}
+	report, err := source.Report(ctx, check)
+	if err != nil {
+		return ""
+	}
+	if report.Error != "" {
+		return report.Error
 	}
+	return report.Notes
 }
 
-// GetReportData extracts information about the given scan from
-// both vulcan-persistence API and vulcan-results API
-func GetReportData(conf config.Config, scanID string) (*ReportData, error) {
-	m := db.NewMemDB()
-	g := groupie.New(m)
+func (rp *ReportData) addCoverage(check persistence.Check, reason string) {
+	if t := checkAssetType(check); t != "" {
+		rp.AssetTypes[check.Target] = t
+	}
+	status := check.Status
+	if status == "" {
+		status = StatusFinished
+	}
+	rp.coverage[check.Target] = append(rp.coverage[check.Target], CheckCoverage{
+		CheckType: check.CheckTypeName,
+		Status:    status,
+		Reason:    reason,
+	})
+}
+
+// GetReportData extracts information about the given scan from the
+// ReportSource defined in the config. By default, both vulcan-persistence API
+// and vulcan-results API.
+func GetReportData(ctx context.Context, conf config.Config, scanID string) (*ReportData, error) {
+	transport, err := NewHTTPTransport(conf)
+	if err != nil {
+		return nil, err
+	}
+	source, err := NewReportSource(conf, transport)
+	if err != nil {
+		return nil, err
+	}
+	return GetReportDataFromSource(ctx, conf, source, scanID, Options{})
+}
+
+// Options contains the optional settings of the collection of the data of a
+// scan.
+type Options struct {
+	// Progress receives the progress of the collection. By default it is
+	// logged.
+	Progress ProgressFunc
+	// DB is the grouping database updated with the scan, for instance a
+	// FileDB. By default a new in-memory database is used, so the groups only
+	// include the scan.
+	DB db.DB
+	// Suppressions contains the rules of the findings excluded from the
+	// report. By default no finding is suppressed.
+	Suppressions *Suppressions
+	// Policy contains the rules overriding the score of the findings. By
+	// default the scores are not modified.
+	Policy *Policy
+	// Inventory provides the metadata of the assets, which is set in the
+	// report data and matched by the rules of the policy. By default the
+	// assets have no metadata.
+	Inventory Inventory
+}
 
-	rp := &ReportData{ScanID: scanID, countChecks: 0, chanChecks: make(chan persistence.Check, conf.Results.Workers), groupie: g}
+// GetReportDataFromSource extracts information about the given scan from the
+// given ReportSource. If the context is done before all the reports are
+// fetched, a *PartialCollectionError is returned.
+func GetReportDataFromSource(ctx context.Context, conf config.Config, source ReportSource, scanID string, opts Options) (*ReportData, error) {
+	policy := conf.Results.ErrorPolicy
+	if policy == "" {
+		policy = ErrorPolicyCollectAll
+	}
+	if policy != ErrorPolicyCollectAll && policy != ErrorPolicyFirstError {
+		return nil, fmt.Errorf("unknown error policy: %s", policy)
+	}
+	progress := opts.Progress
+	if progress == nil {
+		progress = logProgress
+	}
+	gdb := opts.DB
+	if gdb == nil {
+		gdb = db.NewMemDB()
+	}
+	g := groupie.New(gdb)
+
+	rp := &ReportData{
+		ScanID:        scanID,
+		MissingChecks: []MissingCheck{},
+		countChecks:   0,
+		AssetTypes:    make(map[string]string),
+		coverage:      make(map[string][]CheckCoverage),
+		groupie:       g,
+		retry: retryPolicy{
+			Retries:    conf.Results.Retries,
+			Backoff:    conf.Results.RetryBackoff,
+			MaxBackoff: conf.Results.MaxRetryBackoff,
+		},
+	}
 	//We need to retrieve the scan date because the reports on vulcan Results
 	//are partitioned by date
-	date, err := persistence.GetDate(conf.Persistence.Endpoint, rp.ScanID)
+	date, err := source.ScanDate(ctx, rp.ScanID)
 	if err != nil {
 		return nil, err
 	}
 	rp.Date = date
+	rp.aggregator = newAggregator(scanID, date, g, opts.Suppressions, opts.Policy, opts.Inventory)
 
-	log.Printf("Getting checks from persistence api...")
-	checks, err := persistence.GetChecks(conf.Persistence.Endpoint, rp.ScanID)
-	if err != nil {
-		return nil, err
+	if conf.Results.RateLimit > 0 {
+		limiter := newRateLimiter(conf.Results.RateLimit)
+		defer limiter.stop()
+		source = &rateLimitedSource{ReportSource: source, limiter: limiter}
 	}
 
-	log.Printf("Getting reports from results api...")
-	rp.Reports = []vulcanreport.Report{}
-	ctx, done := context.WithCancel(context.Background())
+	log.Printf("Getting checks and reports...")
+	tracker := newProgressTracker(progress)
+	group, groupCtx := withGroup(ctx)
+	chanChecks := make(chan persistence.Check, conf.Results.Workers)
+	chanResults := make(chan checkResult, conf.Results.Workers)
+
+	// The checks are sent to the workers as they are retrieved, so the
+	// reports can be fetched while the remaining pages of checks are
+	// requested. If the checks can not be retrieved the report would be
+	// incomplete, so the error stops the workers.
+	nChecks := 0
+	group.Go(func() error {
+		defer close(chanChecks)
+		checks := source.Checks(rp.ScanID)
+		t, hasTotal := checks.(totaler)
+		for checks.Next(groupCtx) {
+			if hasTotal && t.Total() >= 0 {
+				tracker.setTotal(t.Total())
+			}
+			select {
+			case chanChecks <- checks.Check():
+				nChecks++
+			case <-groupCtx.Done():
+				return groupCtx.Err()
+			}
+		}
+		if err := checks.Err(); err != nil {
+			return err
+		}
+		tracker.setTotal(nChecks)
+		return nil
+	})
+
+	// The number of checks processed concurrently is bounded by the number
+	// of workers.
+	var workersWG sync.WaitGroup
 	for i := 0; i < conf.Results.Workers; i++ {
-		rp.workerWG.Add(1)
-		go rp.worker(ctx.Done(), conf)
+		workersWG.Add(1)
+		group.Go(func() error {
+			defer workersWG.Done()
+			for check := range chanChecks {
+				res := rp.process(groupCtx, source, check)
+				if res.err != nil && policy == ErrorPolicyFirstError && groupCtx.Err() == nil {
+					return fmt.Errorf("error getting results for check-id %s: %w", check.ID, res.err)
+				}
+				select {
+				case chanResults <- res:
+				case <-groupCtx.Done():
+					return groupCtx.Err()
+				}
+			}
+			return nil
+		})
 	}
-
-	log.Printf("Sending %d checks to channel...", len(checks))
-	for _, check := range checks {
-		rp.reportWG.Add(1)
-		rp.chanChecks <- check
+	go func() {
+		workersWG.Wait()
+		close(chanResults)
+	}()
+
+	// The results are collected by this goroutine only, so the report data
+	// does not need to be locked.
+	for res := range chanResults {
+		// Once the collection is stopped the errors are caused by the
+		// cancellation, so they are ignored.
+		if res.err != nil && groupCtx.Err() != nil {
+			continue
+		}
+		rp.collect(ctx, res)
+		tracker.update(len(rp.MissingChecks))
+	}
+	err = group.Wait()
+	log.Printf("%d checks processed", nChecks)
+
+	if err := ctx.Err(); err != nil {
+		// The number of checks of the scan is known before all of them are
+		// retrieved if the source provides it.
+		total := tracker.totalChecks()
+		if total < 0 {
+			total = nChecks
+		}
+		return nil, &PartialCollectionError{
+			ScanID:    scanID,
+			Collected: rp.countChecks,
+			Total:     total,
+			Err:       err,
+		}
 	}
 
-	// This waits for all the reports to be done.
-	rp.reportWG.Wait()
+	if err != nil {
+		return nil, err
+	}
 
-	// Signal workers to finish.
-	done()
-	// This waits for all workers to be finished.
-	rp.workerWG.Wait()
+	// In offline mode a missing report means it is not cached.
+	if conf.Cache.Offline && len(rp.MissingChecks) > 0 {
+		return nil, fmt.Errorf("%w: the results of %d checks", cache.ErrOffline, len(rp.MissingChecks))
+	}
 
-	rp.setRisk()
-	rp.setActionRequired()
-	rp.setAssets()
-	rp.setChecktypes()
-	rp.setVulnerabilitiesPerImpact()
-	rp.setVulnerabilitiesPerAssets()
-	rp.setTopVulnerabilities()
-	rp.setNumberOfVulnerableAssets()
-	rp.setAllVulnerabilities()
+	if len(rp.MissingChecks) > 0 {
+		log.Printf("WARNING the report is incomplete: the results of %d of %d checks could not be retrieved", len(rp.MissingChecks), nChecks)
+	}
 
-	// Update grouping database with scan before retrieving groups.
-	if err := g.UpdateFromScan(scanID, date, rp.Reports); err != nil {
+	// The grouping database has been updated with every report, so the
+	// groups can be retrieved.
+	if err := rp.aggregator.apply(rp); err != nil {
 		return nil, err
 	}
+	// The assets without findings, or whose checks failed, are also
+	// enriched.
+	if opts.Inventory != nil {
+		if err := rp.EnrichAssets(ctx, opts.Inventory); err != nil {
+			return nil, fmt.Errorf("error enriching the assets: %w", err)
+		}
+	}
+	rp.setMissingChecks()
+	rp.setCoverage()
 
 	if err := rp.setGroups(); err != nil {
 		return nil, err
@@ -123,11 +308,11 @@ func GetReportDataFromFile(conf config.Config, scanID, path string) (*ReportData
 	m := db.NewMemDB()
 	g := groupie.New(m)
 
-	rp := &ReportData{ScanID: scanID, countChecks: 0, groupie: g}
+	rp := &ReportData{ScanID: scanID, MissingChecks: []MissingCheck{}, AssetTypes: make(map[string]string), countChecks: 0, coverage: make(map[string][]CheckCoverage), groupie: g}
 	date := time.Now().Format("2006-01-02")
 	rp.Date = date
+	rp.aggregator = newAggregator(scanID, date, g, nil, nil, nil)
 	log.Printf("Getting reports from results json file...")
-	rp.Reports = []vulcanreport.Report{}
 
 	content, err := os.ReadFile(path)
 	if err != nil {
@@ -138,21 +323,12 @@ func GetReportDataFromFile(conf config.Config, scanID, path string) (*ReportData
 	if err != nil {
 		return nil, err
 	}
-	rp.Reports = append(rp.Reports, r)
-	rp.setRisk()
-	rp.setActionRequired()
-	rp.setAssets()
-	rp.setChecktypes()
-	rp.setVulnerabilitiesPerImpact()
-	rp.setVulnerabilitiesPerAssets()
-	rp.setTopVulnerabilities()
-	rp.setNumberOfVulnerableAssets()
-	rp.setAllVulnerabilities()
-
-	// Update grouping database with scan before retrieving groups.
-	if err := g.UpdateFromScan(scanID, date, rp.Reports); err != nil {
+	rp.addCoverage(persistence.Check{ID: r.CheckID, Target: r.Target, Status: r.Status, CheckTypeName: r.ChecktypeName}, r.Error)
+	rp.aggregator.add(context.Background(), &r, rp.AssetTypes[r.Target])
+	if err := rp.aggregator.apply(rp); err != nil {
 		return nil, err
 	}
+	rp.setCoverage()
 
 	if err := rp.setGroups(); err != nil {
 		return nil, err
@@ -165,241 +341,40 @@ func GetReportDataFromFile(conf config.Config, scanID, path string) (*ReportData
 	return rp, nil
 }
 
-// Risk is defined as the maximum severity found among all vulnerabilities
-func (rp *ReportData) setRisk() {
-	severityLevel := vulcanreport.SeverityNone
-loop:
-	for _, report := range rp.Reports {
-		for _, vuln := range report.Vulnerabilities {
-			severity := vuln.Severity()
-			if severity > severityLevel {
-				severityLevel = severity
-			}
-
-			//currently the max severity level is 4: critical
-			if severityLevel == vulcanreport.SeverityCritical {
-				break loop
-			}
+// sort the checks whose results could not be retrieved
+func (rp *ReportData) setMissingChecks() {
+	sort.SliceStable(rp.MissingChecks, func(i, j int) bool {
+		if rp.MissingChecks[i].Target == rp.MissingChecks[j].Target {
+			return rp.MissingChecks[i].CheckType < rp.MissingChecks[j].CheckType
 		}
-	}
-
-	rp.Risk = severityLevel
-}
-
-// An action is required if the risk is high or critical
-func (rp *ReportData) setActionRequired() {
-	rp.ActionRequired = rp.Risk >= vulcanreport.SeverityHigh
-}
-
-// Find all scanned assets in this report
-func (rp *ReportData) setAssets() {
-	assets := []string{}
-	assetMap := make(map[string]bool)
-
-	for _, report := range rp.Reports {
-		assetMap[report.Target] = true
-	}
-
-	for asset := range assetMap {
-		assets = append(assets, asset)
-	}
-
-	sort.Strings(sort.StringSlice(assets))
-
-	rp.Assets = assets
-}
-
-// Find all checktypes in this report
-func (rp *ReportData) setChecktypes() {
-	checktypes := []string{}
-	checktypeMap := make(map[string]bool)
-
-	for _, report := range rp.Reports {
-		checktypeMap[report.ChecktypeName] = true
-	}
-
-	for checktype := range checktypeMap {
-		checktypes = append(checktypes, checktype)
-	}
-	sort.Strings(sort.StringSlice(checktypes))
-
-	rp.CheckTypes = checktypes
-}
-
-// populates an array containing the number of vulnerabilities per checktype
-func (rp *ReportData) setVulnerabilitiesPerImpact() {
-	// in the cases where a report does not contains any vulnerabilities,
-	// the pie chart library will complain about not being able to Generate
-	// a chart with only zero values. By putting a 0.01 we can work around
-	// this situation.
-	vulnerabilitiesMap := map[string]float64{
-		"Info":     0.01,
-		"Low":      0.01,
-		"Medium":   0.01,
-		"High":     0.01,
-		"Critical": 0.01,
-	}
-	result := []VulnerabilitiesPerImpact{}
-
-	for _, report := range rp.Reports {
-		for _, vulnerability := range report.Vulnerabilities {
-			severity := vulnerability.Severity()
-			vulnerabilitiesMap[severityToString(severity)] = vulnerabilitiesMap[severityToString(severity)] + 1
-		}
-	}
-
-	result = append(result, VulnerabilitiesPerImpact{Impact: "Critical", Vulnerabilities: vulnerabilitiesMap["Critical"]})
-	result = append(result, VulnerabilitiesPerImpact{Impact: "High", Vulnerabilities: vulnerabilitiesMap["High"]})
-	result = append(result, VulnerabilitiesPerImpact{Impact: "Medium", Vulnerabilities: vulnerabilitiesMap["Medium"]})
-	result = append(result, VulnerabilitiesPerImpact{Impact: "Low", Vulnerabilities: vulnerabilitiesMap["Low"]})
-	result = append(result, VulnerabilitiesPerImpact{Impact: "Info", Vulnerabilities: vulnerabilitiesMap["Info"]})
-
-	rp.VulnerabilitiesPerImpact = result
-}
-
-// populates an array containing the assets ordered by number of vulnerabilities
-func (rp *ReportData) setVulnerabilitiesPerAssets() {
-	var assetVulnerabilitiesMap = make(map[string]int)
-
-	var result = []VulnerabilitiesPerAsset{}
-
-	// first we get the Vulnerability number per assets
-	for _, report := range rp.Reports {
-		numVulnerabilities := 0
-		for _, v := range report.Vulnerabilities {
-			//Ignore INFO
-			severity := v.Severity()
-			if severity != vulcanreport.SeverityNone {
-				numVulnerabilities++
-			}
-		}
-
-		assetVulnerabilitiesMap[report.Target] = assetVulnerabilitiesMap[report.Target] + numVulnerabilities
-	}
-
-	// hen we created a slice of AssetVulnerability
-	for target, numVulnerabilities := range assetVulnerabilitiesMap {
-		assetVulnerability := VulnerabilitiesPerAsset{Asset: target, Vulnerabilities: numVulnerabilities}
-		result = append(result, assetVulnerability)
-	}
-
-	sort.SliceStable(result, func(i, j int) bool {
-		if result[i].Vulnerabilities == result[j].Vulnerabilities {
-			return result[i].Asset > result[j].Asset
-		}
-		return result[i].Vulnerabilities > result[j].Vulnerabilities
+		return rp.MissingChecks[i].Target < rp.MissingChecks[j].Target
 	})
-
-	rp.VulnerabilitiesPerAsset = result
-}
-
-// find the number of vulnerables assets
-func (rp *ReportData) setNumberOfVulnerableAssets() {
-	var vulnerableAssets = make(map[string]bool)
-
-	// first we get the Vulnerability number per assets
-	for _, report := range rp.Reports {
-		numVulnerabilities := len(report.Vulnerabilities)
-		if numVulnerabilities > 0 {
-			vulnerableAssets[report.Target] = true
-		}
-	}
-
-	rp.NumberOfVulnerableAssets = len(vulnerableAssets)
 }
 
-// find all vulnerabilties on a report
-func (rp *ReportData) setTopVulnerabilities() {
-	vulnerabilitiesCountMap := make(map[string]map[string]VulnerabilityCount)
-	result := []VulnerabilityCount{}
-
-	// first we get the Vulnerability number per assets
-	for _, report := range rp.Reports {
-		for _, vuln := range report.Vulnerabilities {
-			title := vuln.Summary
-			impact := severityToString(vuln.Severity())
-			// check if specific vulnerability exists
-			if _, ok := vulnerabilitiesCountMap[title]; !ok {
-				vulnerabilitiesCountMap[title] = make(map[string]VulnerabilityCount)
-				vulnerabilitiesCountMap[title][impact] = VulnerabilityCount{
-					Summary: vuln.Summary,
-					Impact:  severityToString(vuln.Severity()),
-					Count:   1,
-				}
-				continue
-			}
-			// if specific vulnerability exists: count and group by impact
-			if _, ok := vulnerabilitiesCountMap[title][impact]; !ok {
-				vulnerabilitiesCountMap[title][impact] = VulnerabilityCount{
-					Summary: vuln.Summary,
-					Impact:  severityToString(vuln.Severity()),
-					Count:   1,
-				}
-				continue
-			}
-			vulnerabilitiesCountPerImpact := vulnerabilitiesCountMap[title][impact]
-			vulnerabilitiesCountPerImpact.Count++
-			vulnerabilitiesCountMap[title][impact] = vulnerabilitiesCountPerImpact
-		}
-	}
-
-	// then we created a slice of AssetVulnerabilit with Severity over Low and None
-	for _, vuln := range vulnerabilitiesCountMap {
-		for _, vulnPerImpact := range vuln {
-			if vulnPerImpact.Impact != severityToString(vulcanreport.SeverityNone) && vulnPerImpact.Impact != severityToString(vulcanreport.SeverityLow) {
-				result = append(result, vulnPerImpact)
+// build the coverage of the scan per asset, sorted by asset and checktype
+func (rp *ReportData) setCoverage() {
+	result := []AssetCoverage{}
+	for asset, checks := range rp.coverage {
+		ac := AssetCoverage{Asset: asset, Checks: checks}
+		for _, c := range checks {
+			if c.Status != StatusFinished || c.Reason != "" {
+				ac.Failed++
 			}
 		}
-	}
-
-	sort.SliceStable(result, func(i, j int) bool {
-		if severityStringToInt(result[i].Impact) == severityStringToInt(result[j].Impact) {
-			if result[i].Count == result[j].Count {
-				return result[i].Summary < result[j].Summary
-			}
-
-			return result[i].Count > result[j].Count
-		}
-
-		return severityStringToInt(result[i].Impact) > severityStringToInt(result[j].Impact)
-	})
-
-	if len(result) > 10 {
-		rp.TopVulnerabilities = result[0:9]
-	} else {
-		rp.TopVulnerabilities = result
-	}
-}
-
-// find all vulnerabilties on a report
-func (rp *ReportData) setAllVulnerabilities() {
-	result := []Vulnerability{}
-
-	for _, report := range rp.Reports {
-		for _, vulnerability := range report.Vulnerabilities {
-			vulnerabilityDescription := Vulnerability{
-				Asset:         report.Target,
-				CheckType:     report.ChecktypeName,
-				Vulnerability: vulnerability,
-				Options:       report.Options,
+		sort.SliceStable(ac.Checks, func(i, j int) bool {
+			if ac.Checks[i].CheckType == ac.Checks[j].CheckType {
+				return ac.Checks[i].Status < ac.Checks[j].Status
 			}
-			result = append(result, vulnerabilityDescription)
-		}
+			return ac.Checks[i].CheckType < ac.Checks[j].CheckType
+		})
+		result = append(result, ac)
 	}
 
 	sort.SliceStable(result, func(i, j int) bool {
-		if result[i].Vulnerability.Severity() == result[j].Vulnerability.Severity() {
-			if result[i].Asset == result[j].Asset {
-				if result[i].CheckType == result[j].CheckType {
-					return result[i].CheckType < result[j].CheckType
-				}
-			}
-			return result[i].Asset < result[j].Asset
-		}
-		return result[i].Vulnerability.Severity() > result[j].Vulnerability.Severity()
+		return result[i].Asset < result[j].Asset
 	})
 
-	rp.Vulnerabilities = result
+	rp.Coverage = result
 }
 
 func (rp *ReportData) setGroups() error {
@@ -0,0 +1,65 @@
+package vulcan
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/adevinta/security-overview/config"
+)
+
+func TestGetReportDataFromSourceCoverage(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"scan/scan.json": `{"id":"scan","start_time":"2022-10-12T10:00:00Z"}`,
+		"scan/checks.json": `{"checks":[
+			{"id":"c1","target":"a.example.com","status":"FINISHED","checktype_name":"vulcan-tls"},
+			{"id":"c2","target":"a.example.com","status":"FAILED","checktype_name":"vulcan-nmap"},
+			{"id":"c3","target":"a.example.com","status":"ABORTED","checktype_name":"vulcan-zap"},
+			{"id":"c4","target":"b.example.com","status":"TIMEOUT","checktype_name":"vulcan-zap"},
+			{"id":"c5","target":"b.example.com","status":"INCONCLUSIVE","checktype_name":"vulcan-nmap"},
+			{"id":"c6","target":"b.example.com","status":"FINISHED","checktype_name":"vulcan-tls"}
+		]}`,
+		"scan/reports/c1.json": `{"check_id":"c1","checktype_name":"vulcan-tls","status":"FINISHED","target":"a.example.com","start_time":"2022-10-12 10:00:00"}`,
+		"scan/reports/c2.json": `{"check_id":"c2","checktype_name":"vulcan-nmap","status":"FAILED","target":"a.example.com","start_time":"2022-10-12 10:00:00","error":"connection refused"}`,
+		"scan/reports/c4.json": `{"check_id":"c4","checktype_name":"vulcan-zap","status":"TIMEOUT","target":"b.example.com","start_time":"2022-10-12 10:00:00","notes":"Timeout after 10m"}`,
+		"scan/reports/c5.json": `{"check_id":"c5","checktype_name":"vulcan-nmap","status":"INCONCLUSIVE","target":"b.example.com","start_time":"2022-10-12 10:00:00","error":"host seems down"}`,
+	})
+	conf := config.Config{}
+	conf.Results.Workers = 1
+	conf.Results.Retries = -1
+	rd, err := GetReportDataFromSource(context.Background(), conf, &DirSource{Dir: dir}, "scan", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The checks that did not finish are part of the coverage of their assets
+	// with the reason taken from their reports, if any.
+	want := []AssetCoverage{
+		{
+			Asset: "a.example.com",
+			Checks: []CheckCoverage{
+				{CheckType: "vulcan-nmap", Status: "FAILED", Reason: "connection refused"},
+				{CheckType: "vulcan-tls", Status: "FINISHED"},
+				{CheckType: "vulcan-zap", Status: "ABORTED"},
+			},
+			Failed: 2,
+		},
+		{
+			Asset: "b.example.com",
+			Checks: []CheckCoverage{
+				{CheckType: "vulcan-nmap", Status: "INCONCLUSIVE", Reason: "host seems down"},
+				{CheckType: "vulcan-tls", Status: "FINISHED", Reason: "The results of the check could not be retrieved"},
+				{CheckType: "vulcan-zap", Status: "TIMEOUT", Reason: "Timeout after 10m"},
+			},
+			Failed: 3,
+		},
+	}
+	if !reflect.DeepEqual(rd.Coverage, want) {
+		t.Errorf("unexpected coverage: got %+v, want %+v", rd.Coverage, want)
+	}
+	// Only the finished check whose report is missing is reported as missing.
+	if len(rd.MissingChecks) != 1 || rd.MissingChecks[0].CheckID != "c6" {
+		t.Errorf("unexpected missing checks: %+v", rd.MissingChecks)
+	}
+}